@@ -11,14 +11,23 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/qdm12/dns/internal/admin"
 	"github.com/qdm12/dns/internal/config"
 	"github.com/qdm12/dns/internal/health"
 	"github.com/qdm12/dns/internal/models"
+	"github.com/qdm12/dns/internal/prometheus"
 	"github.com/qdm12/dns/internal/splash"
 	"github.com/qdm12/dns/pkg/blacklist"
+	"github.com/qdm12/dns/pkg/cache"
 	"github.com/qdm12/dns/pkg/check"
+	"github.com/qdm12/dns/pkg/dnssec"
+	"github.com/qdm12/dns/pkg/doh"
+	"github.com/qdm12/dns/pkg/dot"
+	"github.com/qdm12/dns/pkg/metrics"
 	"github.com/qdm12/dns/pkg/nameserver"
+	"github.com/qdm12/dns/pkg/querylog"
 	"github.com/qdm12/dns/pkg/unbound"
+	"github.com/qdm12/dns/pkg/upstream"
 	"github.com/qdm12/golibs/logging"
 	customOS "github.com/qdm12/golibs/os"
 	"github.com/qdm12/updated/pkg/dnscrypto"
@@ -105,18 +114,33 @@ func _main(ctx context.Context, buildInfo models.BuildInformation,
 		return dnsConf.SetupFiles(ctx)
 	}
 
-	version, err := dnsConf.Version(ctx)
+	settings, err := configReader.ReadSettings()
 	if err != nil {
 		return err
 	}
-	logger.Info("Unbound version: %s", version)
 
-	settings, err := configReader.ReadSettings()
-	if err != nil {
-		return err
+	if settings.DoT.DNSSEC.Enabled || settings.DoH.DNSSEC.Enabled {
+		rootKeys, err := dnsConf.RootKeys()
+		if err != nil {
+			return fmt.Errorf("cannot read DNSSEC root keys: %w", err)
+		}
+		trustAnchors, err := dnssec.ParseTrustAnchors(rootKeys)
+		if err != nil {
+			return err
+		}
+		settings.DoT.DNSSEC.TrustAnchors = trustAnchors
+		settings.DoH.DNSSEC.TrustAnchors = trustAnchors
 	}
 	logger.Info("Settings summary:\n" + settings.String())
 
+	if settings.Resolver == config.ResolverUnbound {
+		version, err := dnsConf.Version(ctx)
+		if err != nil {
+			return err
+		}
+		logger.Info("Unbound version: %s", version)
+	}
+
 	wg := &sync.WaitGroup{}
 	defer wg.Wait()
 	crashed := make(chan error)
@@ -128,11 +152,30 @@ func _main(ctx context.Context, buildInfo models.BuildInformation,
 	wg.Add(1)
 	go healthServer.Run(ctx, wg)
 
+	if settings.Metrics.Enabled {
+		prometheusMetrics := metrics.NewPrometheus()
+		settings.DoT.Metrics = prometheusMetrics
+		settings.DoT.Cache.LRU.Metrics = prometheusMetrics
+		settings.DoH.Metrics = prometheusMetrics
+		settings.DoH.Cache.LRU.Metrics = prometheusMetrics
+		metricsServer := prometheus.NewServer(settings.Metrics.Address,
+			logger.NewChild(logging.Settings{Prefix: "metrics server: "}),
+			prometheusMetrics)
+		wg.Add(1)
+		go metricsServer.Run(ctx, wg)
+	}
+
 	localIP := net.IP{127, 0, 0, 1}
 	logger.Info("using DNS address %s internally", localIP.String())
-	nameserver.UseDNSInternally(localIP) // use Unbound
+	nameserver.UseDNSInternally(localIP) // use the local DNS server
 	wg.Add(1)
-	go unboundRunLoop(ctx, wg, settings, logger, dnsConf, client, crashed)
+	if settings.Resolver == config.ResolverUnbound {
+		go unboundRunLoop(ctx, wg, settings, logger, dnsConf, client, crashed)
+	} else {
+		serverLogger := logger.NewChild(logging.Settings{Prefix: "dns server: "})
+		adminLogger := logger.NewChild(logging.Settings{Prefix: "admin server: "})
+		go dnsServerRunLoop(ctx, wg, settings, logger, serverLogger, adminLogger, client, crashed)
+	}
 
 	select {
 	case <-ctx.Done():
@@ -172,21 +215,7 @@ func unboundRunLoop(ctx context.Context, wg *sync.WaitGroup, settings config.Set
 				logAndWait(ctx, logger, err)
 				continue
 			}
-			logger.Info("downloading and building DNS block lists")
-			blacklistBuilder := blacklist.NewBuilder(client)
-			blockedHostnames, blockedIPs, blockedIPPrefixes, errs :=
-				blacklistBuilder.All(ctx, settings.Blacklist)
-			for _, err := range errs {
-				logger.Warn(err)
-			}
-			logger.Info("%d hostnames blocked overall", len(blockedHostnames))
-			logger.Info("%d IP addresses blocked overall", len(blockedIPs))
-			logger.Info("%d IP networks blocked overall", len(blockedIPPrefixes))
-			settings.Unbound.Blacklist = blacklist.Settings{
-				FqdnHostnames: blockedHostnames,
-				IPs:           blockedIPs,
-				IPPrefixes:    blockedIPPrefixes,
-			}
+			settings.Unbound.Blacklist = buildBlacklist(ctx, logger, client, settings.Blacklist)
 		}
 
 		logger.Info("generating Unbound configuration")
@@ -249,6 +278,121 @@ func unboundRunLoop(ctx context.Context, wg *sync.WaitGroup, settings config.Set
 	unboundCancel()
 }
 
+type dnsServer interface {
+	Run(ctx context.Context, stopped chan<- error)
+	UpdateBlacklist(settings blacklist.Settings)
+	UpdatePolicyBlacklist(name string, settings blacklist.Settings) error
+	Caches() (policyToCache map[string]cache.Cache)
+	QueryLog() (entries []querylog.Entry)
+	FailingUpstreams() (policyToUpstreams map[string][]upstream.Health)
+}
+
+func newDNSServer(ctx context.Context, logger logging.Logger,
+	settings config.Settings) dnsServer {
+	switch settings.Resolver {
+	case config.ResolverDoH:
+		return doh.NewServer(ctx, logger, settings.DoH)
+	default:
+		return dot.NewServer(ctx, logger, settings.DoT)
+	}
+}
+
+// dnsServerRunLoop runs the built-in DoT or DoH DNS server
+// and periodically swaps in updated block lists, without
+// restarting the server.
+func dnsServerRunLoop(ctx context.Context, wg *sync.WaitGroup, settings config.Settings,
+	logger, serverLogger, adminLogger logging.Logger, client *http.Client, crashed chan<- error) {
+	defer wg.Done()
+	defer logger.Info("DNS server loop exited")
+
+	serverCtx, serverCancel := context.WithCancel(ctx)
+	defer serverCancel()
+	server := newDNSServer(serverCtx, serverLogger, settings)
+
+	if settings.Admin.Enabled {
+		adminServer := admin.NewServer(settings.Admin.Address, settings.Admin.Token, adminLogger, server)
+		wg.Add(1)
+		go adminServer.Run(ctx, wg)
+	}
+
+	stopped := make(chan error)
+	logger.Info("starting DNS server")
+	go server.Run(serverCtx, stopped)
+
+	if settings.CheckDNS {
+		// The block lists can only be downloaded once
+		// the DNS server is running.
+		if err := check.WaitForDNS(ctx, net.DefaultResolver); err != nil {
+			serverCancel()
+			<-stopped
+			if ctx.Err() == nil {
+				crashed <- err
+			}
+			return
+		}
+	}
+
+	var updateTick <-chan time.Time // nil if updates are disabled
+	if settings.UpdatePeriod > 0 {
+		ticker := time.NewTicker(settings.UpdatePeriod)
+		defer ticker.Stop()
+		updateTick = ticker.C
+	}
+
+	for {
+		blacklistSettings := buildBlacklist(ctx, logger, client, settings.Blacklist)
+		if ctx.Err() == nil {
+			server.UpdateBlacklist(blacklistSettings)
+			logger.Info("DNS block lists updated")
+		}
+
+		for _, policy := range settings.Policies {
+			logger.Info("building DNS block lists for policy " + policy.Name)
+			blacklistSettings := buildBlacklist(ctx, logger, client, policy.Blacklist)
+			if ctx.Err() != nil {
+				break
+			}
+			err := server.UpdatePolicyBlacklist(policy.Name, blacklistSettings)
+			if err != nil {
+				logger.Error(err)
+			}
+		}
+
+		select {
+		case <-updateTick:
+			logger.Info("planned update of DNS block lists")
+		case <-ctx.Done():
+			logger.Warn("context canceled: exiting DNS server run loop")
+			serverCancel()
+			<-stopped
+			return
+		case err := <-stopped:
+			crashed <- err
+			return
+		}
+	}
+}
+
+func buildBlacklist(ctx context.Context, logger logging.Logger,
+	client *http.Client, builderSettings blacklist.BuilderSettings) (
+	settings blacklist.Settings) {
+	logger.Info("downloading and building DNS block lists")
+	blacklistBuilder := blacklist.NewBuilder(client)
+	blockedHostnames, blockedIPs, blockedIPPrefixes, errs :=
+		blacklistBuilder.All(ctx, builderSettings)
+	for _, err := range errs {
+		logger.Warn(err)
+	}
+	logger.Info("%d hostnames blocked overall", len(blockedHostnames))
+	logger.Info("%d IP addresses blocked overall", len(blockedIPs))
+	logger.Info("%d IP networks blocked overall", len(blockedIPPrefixes))
+	settings.BlockHostnames(blockedHostnames)
+	settings.AllowHostnames(builderSettings.AllowedHosts)
+	settings.IPs = blockedIPs
+	settings.IPPrefixes = blockedIPPrefixes
+	return settings
+}
+
 func logAndWait(ctx context.Context, logger logging.Logger, err error) {
 	const wait = 10 * time.Second
 	logger.Error("%s, retrying in %s", err, wait)
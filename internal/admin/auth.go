@@ -0,0 +1,36 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// authHandler only serves the requests with the bearer token
+// given in their Authorization header, if the token is not empty.
+type authHandler struct {
+	token   []byte
+	handler http.Handler
+}
+
+func newAuthHandler(token string, handler http.Handler) http.Handler {
+	if token == "" {
+		return handler
+	}
+	return &authHandler{
+		token:   []byte(token),
+		handler: handler,
+	}
+}
+
+func (h *authHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const prefix = "Bearer "
+	authorization := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, prefix) ||
+		subtle.ConstantTimeCompare([]byte(authorization[len(prefix):]), h.token) != 1 {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	h.handler.ServeHTTP(w, r)
+}
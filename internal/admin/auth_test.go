@@ -0,0 +1,62 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_authHandler(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		token         string
+		authorization string
+		status        int
+	}{
+		"no token": {
+			status: http.StatusOK,
+		},
+		"missing authorization": {
+			token:  "secret",
+			status: http.StatusUnauthorized,
+		},
+		"wrong scheme": {
+			token:         "secret",
+			authorization: "Basic secret",
+			status:        http.StatusUnauthorized,
+		},
+		"wrong token": {
+			token:         "secret",
+			authorization: "Bearer secreT",
+			status:        http.StatusUnauthorized,
+		},
+		"valid token": {
+			token:         "secret",
+			authorization: "Bearer secret",
+			status:        http.StatusOK,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			okHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+			handler := newAuthHandler(testCase.token, okHandler)
+
+			request := httptest.NewRequest(http.MethodGet, "/cache/entries", nil)
+			if testCase.authorization != "" {
+				request.Header.Set("Authorization", testCase.authorization)
+			}
+			recorder := httptest.NewRecorder()
+
+			handler.ServeHTTP(recorder, request)
+
+			assert.Equal(t, testCase.status, recorder.Code)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/qdm12/dns/pkg/cache"
+)
+
+type cacheHandler struct {
+	policyToCache map[string]cache.Cache
+	policies      []string // sorted policy names
+}
+
+func newCacheHandler(policyToCache map[string]cache.Cache) *cacheHandler {
+	policies := make([]string, 0, len(policyToCache))
+	for policy := range policyToCache {
+		policies = append(policies, policy)
+	}
+	sort.Strings(policies)
+	return &cacheHandler{
+		policyToCache: policyToCache,
+		policies:      policies,
+	}
+}
+
+type cacheEntry struct {
+	Policy string `json:"policy"`
+	cache.Entry
+}
+
+type removedResponse struct {
+	Removed int `json:"removed"`
+}
+
+// ServeHTTP lists the cache entries for GET requests, and removes
+// cache entries for DELETE requests. For DELETE requests, the name
+// query parameter removes the entries for this name only, the suffix
+// query parameter removes the entries for this name and its
+// subdomains, and all the entries are removed if none is set.
+// For both methods, the policy query parameter restricts the
+// operation to the cache of this policy.
+func (h *cacheHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	policies := h.policies
+	if policy := query.Get("policy"); policy != "" {
+		if _, ok := h.policyToCache[policy]; !ok {
+			http.Error(w, "policy not found: "+policy, http.StatusNotFound)
+			return
+		}
+		policies = []string{policy}
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.list(w, policies)
+	case http.MethodDelete:
+		name, suffix := query.Get("name"), query.Get("suffix")
+		if name != "" && suffix != "" {
+			http.Error(w, "name and suffix cannot be both set", http.StatusBadRequest)
+			return
+		}
+		h.remove(w, policies, name, suffix)
+	default:
+		w.Header().Set("Allow", "GET, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *cacheHandler) list(w http.ResponseWriter, policies []string) {
+	entries := []cacheEntry{}
+	for _, policy := range policies {
+		h.policyToCache[policy].Range(func(entry cache.Entry) bool {
+			entries = append(entries, cacheEntry{Policy: policy, Entry: entry})
+			return true
+		})
+	}
+	writeJSON(w, entries)
+}
+
+func (h *cacheHandler) remove(w http.ResponseWriter, policies []string,
+	name, suffix string) {
+	var response removedResponse
+	for _, policy := range policies {
+		c := h.policyToCache[policy]
+		switch {
+		case name != "":
+			response.Removed += c.Remove(name, false)
+		case suffix != "":
+			response.Removed += c.Remove(suffix, true)
+		default:
+			response.Removed += c.Len()
+			c.Flush()
+		}
+	}
+	writeJSON(w, response)
+}
+
+func writeJSON(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	_ = encoder.Encode(data)
+}
@@ -0,0 +1,118 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCache(t *testing.T, names ...string) cache.Cache {
+	t.Helper()
+	settings := cache.Settings{Type: cache.LRU}
+	c := cache.New(settings)
+	for _, name := range names {
+		request := new(dns.Msg).SetQuestion(name, dns.TypeA)
+		response := new(dns.Msg).SetReply(request)
+		response.Answer = []dns.RR{&dns.A{
+			Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+		}}
+		c.Add(request, response)
+	}
+	return c
+}
+
+func Test_cacheHandler(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		method     string
+		target     string
+		status     int
+		body       string
+		remaining  map[string]int
+		bodyPrefix string
+	}{
+		"method not allowed": {
+			method:    http.MethodPost,
+			target:    "/cache/entries",
+			status:    http.StatusMethodNotAllowed,
+			body:      "method not allowed\n",
+			remaining: map[string]int{"default": 3, "kids": 1},
+		},
+		"unknown policy": {
+			method:    http.MethodGet,
+			target:    "/cache/entries?policy=unknown",
+			status:    http.StatusNotFound,
+			body:      "policy not found: unknown\n",
+			remaining: map[string]int{"default": 3, "kids": 1},
+		},
+		"list policy": {
+			method:     http.MethodGet,
+			target:     "/cache/entries?policy=kids",
+			status:     http.StatusOK,
+			bodyPrefix: `[{"policy":"kids","name":"github.com.","type":"A","rcode":"NOERROR","expiry":`,
+			remaining:  map[string]int{"default": 3, "kids": 1},
+		},
+		"name and suffix": {
+			method:    http.MethodDelete,
+			target:    "/cache/entries?name=a.com&suffix=a.com",
+			status:    http.StatusBadRequest,
+			body:      "name and suffix cannot be both set\n",
+			remaining: map[string]int{"default": 3, "kids": 1},
+		},
+		"remove name": {
+			method:    http.MethodDelete,
+			target:    "/cache/entries?name=GitHub.com",
+			status:    http.StatusOK,
+			body:      `{"removed":2}` + "\n",
+			remaining: map[string]int{"default": 2, "kids": 0},
+		},
+		"remove suffix": {
+			method:    http.MethodDelete,
+			target:    "/cache/entries?suffix=github.com.&policy=default",
+			status:    http.StatusOK,
+			body:      `{"removed":2}` + "\n",
+			remaining: map[string]int{"default": 1, "kids": 1},
+		},
+		"flush": {
+			method:    http.MethodDelete,
+			target:    "/cache/entries",
+			status:    http.StatusOK,
+			body:      `{"removed":4}` + "\n",
+			remaining: map[string]int{"default": 0, "kids": 0},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			policyToCache := map[string]cache.Cache{
+				"default": newTestCache(t, "github.com.", "api.github.com.", "google.com."),
+				"kids":    newTestCache(t, "github.com."),
+			}
+			handler := newCacheHandler(policyToCache)
+
+			request := httptest.NewRequest(testCase.method, testCase.target, nil)
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			assert.Equal(t, testCase.status, recorder.Code)
+			if testCase.bodyPrefix != "" {
+				require.Greater(t, recorder.Body.Len(), len(testCase.bodyPrefix))
+				assert.Equal(t, testCase.bodyPrefix, recorder.Body.String()[:len(testCase.bodyPrefix)])
+			} else {
+				assert.Equal(t, testCase.body, recorder.Body.String())
+			}
+			for policy, length := range testCase.remaining {
+				assert.Equal(t, length, policyToCache[policy].Len(), policy)
+			}
+		})
+	}
+}
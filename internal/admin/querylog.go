@@ -0,0 +1,34 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/qdm12/dns/pkg/querylog"
+)
+
+type queryLogHandler struct {
+	queryLog func() (entries []querylog.Entry)
+}
+
+func newQueryLogHandler(queryLog func() (entries []querylog.Entry)) *queryLogHandler {
+	return &queryLogHandler{
+		queryLog: queryLog,
+	}
+}
+
+// ServeHTTP lists the most recent queries kept in memory for GET
+// requests, from the oldest to the most recent one.
+func (h *queryLogHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	entries := h.queryLog()
+	if entries == nil {
+		http.Error(w, "keeping queries in memory is disabled", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, entries)
+}
@@ -0,0 +1,69 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/qdm12/dns/pkg/querylog"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_queryLogHandler(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		method  string
+		entries []querylog.Entry
+		status  int
+		body    string
+	}{
+		"method not allowed": {
+			method: http.MethodDelete,
+			status: http.StatusMethodNotAllowed,
+			body:   "method not allowed\n",
+		},
+		"disabled": {
+			method: http.MethodGet,
+			status: http.StatusNotFound,
+			body:   "keeping queries in memory is disabled\n",
+		},
+		"empty": {
+			method:  http.MethodGet,
+			entries: []querylog.Entry{},
+			status:  http.StatusOK,
+			body:    "[]\n",
+		},
+		"entries": {
+			method: http.MethodGet,
+			entries: []querylog.Entry{{
+				Time:  time.Unix(0, 0).UTC(),
+				Name:  "github.com.",
+				Type:  "A",
+				Rcode: "NOERROR",
+			}},
+			status: http.StatusOK,
+			body: `[{"time":"1970-01-01T00:00:00Z","name":"github.com.","type":"A",` +
+				`"rcode":"NOERROR","latency_ns":0,"cache_hit":false}]` + "\n",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := newQueryLogHandler(func() []querylog.Entry {
+				return testCase.entries
+			})
+
+			request := httptest.NewRequest(testCase.method, "/querylog", nil)
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			assert.Equal(t, testCase.status, recorder.Code)
+			assert.Equal(t, testCase.body, recorder.Body.String())
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/qdm12/dns/pkg/cache"
+	"github.com/qdm12/dns/pkg/querylog"
+	"github.com/qdm12/dns/pkg/upstream"
+	"github.com/qdm12/golibs/logging"
+)
+
+type Server interface {
+	Run(ctx context.Context, wg *sync.WaitGroup)
+}
+
+// DNSServer is the DNS server to administer.
+type DNSServer interface {
+	Caches() (policyToCache map[string]cache.Cache)
+	QueryLog() (entries []querylog.Entry)
+	FailingUpstreams() (policyToUpstreams map[string][]upstream.Health)
+}
+
+type server struct {
+	address string
+	logger  logging.Logger
+	handler http.Handler
+}
+
+// NewServer creates a server serving the administration API for
+// the DNS server given, with its caches on the /cache/entries path,
+// its queries kept in memory on the /querylog path and its failing
+// upstream servers on the /upstreams path.
+// If the token is not empty, requests must have it as bearer token
+// in their Authorization header.
+func NewServer(address, token string, logger logging.Logger,
+	dnsServer DNSServer) Server {
+	mux := http.NewServeMux()
+	mux.Handle("/cache/entries", newCacheHandler(dnsServer.Caches()))
+	mux.Handle("/querylog", newQueryLogHandler(dnsServer.QueryLog))
+	mux.Handle("/upstreams", newUpstreamsHandler(dnsServer.FailingUpstreams))
+	return &server{
+		address: address,
+		logger:  logger,
+		handler: newAuthHandler(token, mux),
+	}
+}
+
+func (s *server) Run(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+	server := http.Server{Addr: s.address, Handler: s.handler}
+	go func() {
+		<-ctx.Done()
+		s.logger.Warn("shutting down (context canceled)")
+		defer s.logger.Warn("shut down")
+		const shutdownGraceDuration = 2 * time.Second
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownGraceDuration)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			s.logger.Error("failed shutting down: %s", err)
+		}
+	}()
+	for ctx.Err() == nil {
+		s.logger.Info("listening on %s", s.address)
+		err := server.ListenAndServe()
+		if err != nil && ctx.Err() == nil { // server crashed
+			s.logger.Error(err)
+			s.logger.Info("restarting")
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package admin
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/qdm12/dns/pkg/upstream"
+)
+
+type upstreamsHandler struct {
+	failingUpstreams func() (policyToUpstreams map[string][]upstream.Health)
+}
+
+func newUpstreamsHandler(failingUpstreams func() (
+	policyToUpstreams map[string][]upstream.Health)) *upstreamsHandler {
+	return &upstreamsHandler{
+		failingUpstreams: failingUpstreams,
+	}
+}
+
+type upstreamHealth struct {
+	Policy string `json:"policy"`
+	upstream.Health
+}
+
+// ServeHTTP lists the upstream servers which failed since their
+// last success for GET requests, with their number of consecutive
+// failures, their last error and the time they are retried at.
+// The policy query parameter restricts the list to this policy.
+func (h *upstreamsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	policyToUpstreams := h.failingUpstreams()
+	policies := make([]string, 0, len(policyToUpstreams))
+	for policy := range policyToUpstreams {
+		policies = append(policies, policy)
+	}
+	sort.Strings(policies)
+
+	if policy := r.URL.Query().Get("policy"); policy != "" {
+		if _, ok := policyToUpstreams[policy]; !ok {
+			http.Error(w, "policy not found: "+policy, http.StatusNotFound)
+			return
+		}
+		policies = []string{policy}
+	}
+
+	upstreams := []upstreamHealth{}
+	for _, policy := range policies {
+		for _, health := range policyToUpstreams[policy] {
+			upstreams = append(upstreams, upstreamHealth{Policy: policy, Health: health})
+		}
+	}
+	writeJSON(w, upstreams)
+}
@@ -0,0 +1,77 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/qdm12/dns/pkg/upstream"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_upstreamsHandler(t *testing.T) {
+	t.Parallel()
+
+	policyToUpstreams := map[string][]upstream.Health{
+		"default": {{
+			Address:   "1.1.1.1:853",
+			Failures:  2,
+			LastError: "dial tcp 1.1.1.1:853: i/o timeout",
+			RetryAt:   time.Unix(0, 0).UTC(),
+		}},
+		"kids": {},
+	}
+
+	testCases := map[string]struct {
+		method string
+		url    string
+		status int
+		body   string
+	}{
+		"method not allowed": {
+			method: http.MethodDelete,
+			url:    "/upstreams",
+			status: http.StatusMethodNotAllowed,
+			body:   "method not allowed\n",
+		},
+		"policy not found": {
+			method: http.MethodGet,
+			url:    "/upstreams?policy=unknown",
+			status: http.StatusNotFound,
+			body:   "policy not found: unknown\n",
+		},
+		"all policies": {
+			method: http.MethodGet,
+			url:    "/upstreams",
+			status: http.StatusOK,
+			body: `[{"policy":"default","address":"1.1.1.1:853","failures":2,` +
+				`"last_error":"dial tcp 1.1.1.1:853: i/o timeout",` +
+				`"retry_at":"1970-01-01T00:00:00Z"}]` + "\n",
+		},
+		"policy without failing upstream": {
+			method: http.MethodGet,
+			url:    "/upstreams?policy=kids",
+			status: http.StatusOK,
+			body:   "[]\n",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := newUpstreamsHandler(func() map[string][]upstream.Health {
+				return policyToUpstreams
+			})
+
+			request := httptest.NewRequest(testCase.method, testCase.url, nil)
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			assert.Equal(t, testCase.status, recorder.Code)
+			assert.Equal(t, testCase.body, recorder.Body.String())
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"github.com/qdm12/golibs/params"
+)
+
+// AdminSettings are settings to serve the administration API over HTTP.
+type AdminSettings struct {
+	Enabled bool
+	Address string
+	// Token is the bearer token required to use the API,
+	// and no authentication is required if it is empty.
+	Token string
+}
+
+// getAdminSettings obtains the administration API settings
+// for the built-in DNS servers.
+func getAdminSettings(reader *reader) (settings AdminSettings, err error) {
+	settings.Enabled, err = reader.env.OnOff("ADMIN", params.Default("off"))
+	if err != nil {
+		return settings, err
+	}
+	settings.Address, err = reader.env.Get("ADMIN_ADDRESS", params.Default("127.0.0.1:8080"))
+	if err != nil {
+		return settings, err
+	}
+	settings.Token, err = reader.env.Get("ADMIN_TOKEN",
+		params.CaseSensitiveValue(), params.Unset())
+	if err != nil {
+		return settings, err
+	}
+	return settings, nil
+}
@@ -3,35 +3,38 @@ package config
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/qdm12/dns/pkg/blacklist"
 	"github.com/qdm12/golibs/params"
 	"inet.af/netaddr"
 )
 
-func getBlacklistSettings(reader *reader) (settings blacklist.BuilderSettings, err error) {
-	settings.BlockMalicious, err = reader.env.OnOff("BLOCK_MALICIOUS", params.Default("on"))
+// getBlacklistSettings obtains the blacklist building settings from
+// environment variables with their keys prefixed with the prefix given.
+func getBlacklistSettings(reader *reader, prefix string) (settings blacklist.BuilderSettings, err error) {
+	settings.BlockMalicious, err = reader.env.OnOff(prefix+"BLOCK_MALICIOUS", params.Default("on"))
 	if err != nil {
 		return settings, err
 	}
-	settings.BlockSurveillance, err = reader.env.OnOff("BLOCK_SURVEILLANCE", params.Default("off"),
-		params.RetroKeys([]string{"BLOCK_NSA"}, reader.onRetroActive))
+	settings.BlockSurveillance, err = reader.env.OnOff(prefix+"BLOCK_SURVEILLANCE", params.Default("off"),
+		params.RetroKeys([]string{prefix + "BLOCK_NSA"}, reader.onRetroActive))
 	if err != nil {
 		return settings, err
 	}
-	settings.BlockAds, err = reader.env.OnOff("BLOCK_ADS", params.Default("off"))
+	settings.BlockAds, err = reader.env.OnOff(prefix+"BLOCK_ADS", params.Default("off"))
 	if err != nil {
 		return settings, err
 	}
-	settings.AllowedHosts, err = getAllowedHostnames(reader)
+	settings.AllowedHosts, err = getAllowedHostnames(reader, prefix)
 	if err != nil {
 		return settings, err
 	}
-	settings.AddBlockedHosts, err = getBlockedHostnames(reader)
+	settings.AddBlockedHosts, err = getBlockedHostnames(reader, prefix)
 	if err != nil {
 		return settings, err
 	}
-	settings.AddBlockedIPs, settings.AddBlockedIPPrefixes, err = getBlockedIPs(reader)
+	settings.AddBlockedIPs, settings.AddBlockedIPPrefixes, err = getBlockedIPs(reader, prefix)
 	if err != nil {
 		return settings, err
 	}
@@ -44,17 +47,68 @@ func getBlacklistSettings(reader *reader) (settings blacklist.BuilderSettings, e
 	return settings, nil
 }
 
+// getBlockResponseSettings obtains the settings for the response sent back
+// to clients for blocked queries, for the built-in DNS servers.
+func getBlockResponseSettings(reader *reader) (settings blacklist.ResponseSettings, err error) {
+	modes := blacklist.ListResponseModes()
+	possibilities := make([]string, len(modes))
+	for i := range modes {
+		possibilities[i] = string(modes[i])
+	}
+	mode, err := reader.env.Inside("BLOCK_RESPONSE", possibilities,
+		params.Default(string(blacklist.ResponseRefused)))
+	if err != nil {
+		return settings, err
+	}
+	settings.Mode, err = blacklist.ParseResponseMode(mode)
+	if err != nil {
+		return settings, err
+	}
+
+	settings.SinkholeIPv4, err = getIP(reader, "BLOCK_SINKHOLE_IPV4", "0.0.0.0")
+	if err != nil {
+		return settings, err
+	}
+	settings.SinkholeIPv6, err = getIP(reader, "BLOCK_SINKHOLE_IPV6", "::")
+	if err != nil {
+		return settings, err
+	}
+
+	const maxTTL = 604800 // one week
+	ttl, err := reader.env.IntRange("BLOCK_RESPONSE_TTL", 1, maxTTL, params.Default("3600"))
+	if err != nil {
+		return settings, err
+	}
+	settings.TTL = uint32(ttl)
+
+	settings.SetDefaults()
+	return settings, nil
+}
+
+func getIP(reader *reader, key, defaultValue string) (ip netaddr.IP, err error) {
+	value, err := reader.env.Get(key, params.Default(defaultValue))
+	if err != nil {
+		return ip, err
+	}
+	ip, err = netaddr.ParseIP(value)
+	if err != nil {
+		return ip, fmt.Errorf("%w: %s: %s", ErrInvalidIPString, key, value)
+	}
+	return ip, nil
+}
+
 var errAllowedHostnameInvalid = errors.New("allowed hostname is invalid")
 
 // getAllowedHostnames obtains a list of hostnames to unblock from block lists
 // from the comma separated list for the environment variable UNBLOCK.
-func getAllowedHostnames(reader *reader) (hostnames []string, err error) {
-	hostnames, err = reader.env.CSV("UNBLOCK")
+// Hostnames can be prefixed with "*." to only unblock their subdomains.
+func getAllowedHostnames(reader *reader, prefix string) (hostnames []string, err error) {
+	hostnames, err = reader.env.CSV(prefix + "UNBLOCK")
 	if err != nil {
 		return nil, err
 	}
 	for _, hostname := range hostnames {
-		if !reader.verifier.MatchHostname(hostname) {
+		if !reader.verifier.MatchHostname(strings.TrimPrefix(hostname, "*.")) {
 			return nil, fmt.Errorf("%w: %s", errAllowedHostnameInvalid, hostname)
 		}
 	}
@@ -65,13 +119,14 @@ var errBlockedHostnameInvalid = errors.New("blocked hostname is invalid")
 
 // getBlockedHostnames obtains a list of hostnames to block from the comma
 // separated list for the environment variable BLOCK_HOSTNAMES.
-func getBlockedHostnames(reader *reader) (hostnames []string, err error) {
-	hostnames, err = reader.env.CSV("BLOCK_HOSTNAMES")
+// Hostnames can be prefixed with "*." to only block their subdomains.
+func getBlockedHostnames(reader *reader, prefix string) (hostnames []string, err error) {
+	hostnames, err = reader.env.CSV(prefix + "BLOCK_HOSTNAMES")
 	if err != nil {
 		return nil, err
 	}
 	for _, hostname := range hostnames {
-		if !reader.verifier.MatchHostname(hostname) {
+		if !reader.verifier.MatchHostname(strings.TrimPrefix(hostname, "*.")) {
 			return nil, fmt.Errorf("%w: %s", errBlockedHostnameInvalid, hostname)
 		}
 	}
@@ -80,9 +135,9 @@ func getBlockedHostnames(reader *reader) (hostnames []string, err error) {
 
 // getBlockedIPs obtains a list of IP addresses and IP networks to block from
 // the comma separated list for the environment variable BLOCK_IPS.
-func getBlockedIPs(reader *reader) (ips []netaddr.IP,
+func getBlockedIPs(reader *reader, prefix string) (ips []netaddr.IP,
 	ipPrefixes []netaddr.IPPrefix, err error) {
-	values, err := reader.env.CSV("BLOCK_IPS")
+	values, err := reader.env.CSV(prefix + "BLOCK_IPS")
 	if err != nil {
 		return nil, nil, err
 	}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"math"
+
+	"github.com/qdm12/dns/pkg/cache"
+	"github.com/qdm12/golibs/params"
+)
+
+func getCacheSettings(reader *reader) (settings cache.Settings, err error) {
+	caching, err := reader.env.OnOff("CACHING", params.Default("off"))
+	if err != nil {
+		return settings, err
+	}
+	settings.Type = cache.Disabled
+	if caching {
+		cacheType, err := reader.env.Inside("CACHE_TYPE",
+			[]string{string(cache.LRU), string(cache.ShardedLRU)},
+			params.Default(string(cache.LRU)))
+		if err != nil {
+			return settings, err
+		}
+		settings.Type, err = cache.ParseCacheType(cacheType)
+		if err != nil {
+			return settings, err
+		}
+	}
+
+	const maxShards = 1024
+	settings.Shards, err = reader.env.IntRange("CACHE_SHARDS", 1, maxShards, params.Default("16"))
+	if err != nil {
+		return settings, err
+	}
+
+	settings.SnapshotPath, err = reader.env.Get("CACHE_SNAPSHOT_PATH")
+	if err != nil {
+		return settings, err
+	}
+
+	settings.LRU.MaxBytes, err = reader.env.IntRange("CACHE_MAX_BYTES", 0, math.MaxInt32, params.Default("0"))
+	if err != nil {
+		return settings, err
+	}
+
+	settings.LRU.MinTTL, err = reader.env.Duration("CACHE_MIN_TTL", params.Default("0s"))
+	if err != nil {
+		return settings, err
+	}
+
+	settings.LRU.MaxTTL, err = reader.env.Duration("CACHE_MAX_TTL", params.Default("24h"))
+	if err != nil {
+		return settings, err
+	}
+
+	settings.LRU.NegativeMaxTTL, err = reader.env.Duration("CACHE_NEGATIVE_MAX_TTL", params.Default("3h"))
+	if err != nil {
+		return settings, err
+	}
+
+	servFailTTL, err := reader.env.Duration("CACHE_SERVFAIL_TTL", params.Default("30s"))
+	if err != nil {
+		return settings, err
+	}
+	settings.LRU.ServFailTTL = &servFailTTL
+
+	settings.LRU.ServeStale, err = reader.env.OnOff("CACHE_SERVE_STALE", params.Default("off"))
+	if err != nil {
+		return settings, err
+	}
+
+	settings.LRU.StaleMaxAge, err = reader.env.Duration("CACHE_STALE_MAX_AGE", params.Default("24h"))
+	if err != nil {
+		return settings, err
+	}
+
+	settings.LRU.Prefetch, err = reader.env.OnOff("CACHE_PREFETCH", params.Default("off"))
+	if err != nil {
+		return settings, err
+	}
+
+	settings.SetDefaults()
+	return settings, nil
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/qdm12/dns/pkg/provider"
+)
+
+// loadProvidersCatalog loads the providers catalog file at the path
+// given by the environment variable PROVIDERS_CATALOG_FILE, if set,
+// to override and extend the built-in providers.
+func loadProvidersCatalog(reader *reader) (err error) {
+	path, err := reader.env.Get("PROVIDERS_CATALOG_FILE")
+	if err != nil {
+		return err
+	} else if path == "" {
+		return nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	err = provider.LoadCatalog(file)
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("file %s: %w", path, err)
+	}
+
+	return file.Close()
+}
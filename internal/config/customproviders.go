@@ -0,0 +1,178 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/qdm12/dns/pkg/provider"
+	"github.com/qdm12/golibs/params"
+)
+
+var (
+	errCustomProviderNameInvalid   = errors.New("custom provider name is invalid")
+	errCustomProviderNameDuplicate = errors.New("custom provider name is duplicated")
+	errCustomProviderIPInvalid     = errors.New("custom provider IP address is invalid")
+	errProviderNoDoT               = errors.New("provider has no DNS over TLS server")
+	errProviderNoDoH               = errors.New("provider has no DNS over HTTPS server")
+)
+
+// getCustomProviders obtains the custom providers from the JSON file
+// at the path given by the environment variable CUSTOM_PROVIDERS_FILE,
+// and from the environment variables prefixed with CUSTOM_PROVIDER_<NAME>_
+// for each name of the comma separated list CUSTOM_PROVIDERS.
+func getCustomProviders(reader *reader) (customs []provider.Provider, err error) {
+	var settings []provider.CustomSettings
+
+	path, err := reader.env.Get("CUSTOM_PROVIDERS_FILE")
+	if err != nil {
+		return nil, err
+	} else if path != "" {
+		fileSettings, err := readCustomProvidersFile(path)
+		if err != nil {
+			return nil, err
+		}
+		settings = append(settings, fileSettings...)
+	}
+
+	names, err := reader.env.CSV("CUSTOM_PROVIDERS")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		envSettings, err := getCustomProvider(reader, name)
+		if err != nil {
+			return nil, fmt.Errorf("custom provider %s: %w", name, err)
+		}
+		settings = append(settings, envSettings)
+	}
+
+	lowercaseNames := make(map[string]struct{}, len(settings))
+	customs = make([]provider.Provider, len(settings))
+	for i, s := range settings {
+		lowercaseName := strings.ToLower(s.Name)
+		if _, ok := lowercaseNames[lowercaseName]; ok {
+			return nil, fmt.Errorf("%w: %s", errCustomProviderNameDuplicate, s.Name)
+		}
+		lowercaseNames[lowercaseName] = struct{}{}
+		customs[i] = provider.Custom(s)
+	}
+
+	return customs, nil
+}
+
+func readCustomProvidersFile(path string) (settings []provider.CustomSettings, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err = provider.ParseCustomJSON(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("file %s: %w", path, err)
+	}
+
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+func getCustomProvider(reader *reader, name string) (
+	settings provider.CustomSettings, err error) {
+	// the name is used in environment variable names
+	// so it has the same constraints as a policy name.
+	if !policyNameRegex.MatchString(name) {
+		return settings, fmt.Errorf("%w: %s", errCustomProviderNameInvalid, name)
+	}
+	settings.Name = name
+	prefix := "CUSTOM_PROVIDER_" + strings.ToUpper(name) + "_"
+
+	settings.DNSIPs, err = getIPs(reader, prefix+"DNS_IPS")
+	if err != nil {
+		return settings, err
+	}
+
+	settings.DoTIPs, err = getIPs(reader, prefix+"DOT_IPS")
+	if err != nil {
+		return settings, err
+	}
+
+	settings.DoTName, err = reader.env.Get(prefix + "DOT_NAME")
+	if err != nil {
+		return settings, err
+	}
+
+	settings.DoTPort, err = reader.env.Port(prefix+"DOT_PORT", params.Default("853"))
+	if err != nil {
+		return settings, err
+	}
+
+	settings.DoHURL, err = reader.env.Get(prefix + "DOH_URL")
+	if err != nil {
+		return settings, err
+	}
+
+	if err := settings.Validate(); err != nil {
+		return settings, err
+	}
+
+	return settings, nil
+}
+
+func getIPs(reader *reader, key string) (ips []net.IP, err error) {
+	values, err := reader.env.CSV(key)
+	if err != nil {
+		return nil, err
+	}
+
+	ips = make([]net.IP, len(values))
+	for i, value := range values {
+		ips[i] = net.ParseIP(value)
+		if ips[i] == nil {
+			return nil, fmt.Errorf("%w: %s", errCustomProviderIPInvalid, value)
+		}
+	}
+	return ips, nil
+}
+
+// checkDoTProviders returns an error if one of the providers,
+// which can be a custom provider, has no DNS over TLS server.
+func checkDoTProviders(providers []provider.Provider) (err error) {
+	for _, p := range providers {
+		if !hasDoT(p) {
+			return fmt.Errorf("%w: %s", errProviderNoDoT, p)
+		}
+	}
+	return nil
+}
+
+// checkDoHProviders returns an error if one of the providers,
+// which can be a custom provider, has no DNS over HTTPS server.
+func checkDoHProviders(providers []provider.Provider) (err error) {
+	for _, p := range providers {
+		if p.DoH().URL == nil {
+			return fmt.Errorf("%w: %s", errProviderNoDoH, p)
+		}
+	}
+	return nil
+}
+
+// filterDoTProviders returns the providers having a DNS over TLS server.
+func filterDoTProviders(providers []provider.Provider) (filtered []provider.Provider) {
+	for _, p := range providers {
+		if hasDoT(p) {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered
+}
+
+func hasDoT(p provider.Provider) bool {
+	dotServer := p.DoT()
+	return len(dotServer.IPv4)+len(dotServer.IPv6) > 0
+}
@@ -0,0 +1,16 @@
+package config
+
+import (
+	"github.com/qdm12/dns/pkg/dnssec"
+	"github.com/qdm12/golibs/params"
+)
+
+// getDNSSECSettings obtains the DNSSEC validation settings for the
+// built-in DNS servers. The trust anchors are set by the caller.
+func getDNSSECSettings(reader *reader) (settings dnssec.Settings, err error) {
+	settings.Enabled, err = reader.env.OnOff("DNSSEC_VALIDATION", params.Default("off"))
+	if err != nil {
+		return settings, err
+	}
+	return settings, nil
+}
@@ -22,15 +22,46 @@ func (s *Settings) Lines(indent, subSection string) (lines []string) {
 		update = fmt.Sprintf("every %s", s.UpdatePeriod)
 	}
 
-	lines = append(lines, subSection+"Unbound settings:")
-	for _, line := range s.Unbound.Lines() {
-		lines = append(lines, indent+line)
+	lines = append(lines, subSection+"Resolver: "+s.Resolver)
+
+	switch s.Resolver {
+	case ResolverDoT:
+		lines = append(lines, subSection+"DNS over TLS settings:")
+		for _, line := range s.DoT.Lines(indent, subSection) {
+			lines = append(lines, indent+line)
+		}
+	case ResolverDoH:
+		lines = append(lines, subSection+"DNS over HTTPS settings:")
+		for _, line := range s.DoH.Lines(indent, subSection) {
+			lines = append(lines, indent+line)
+		}
+	case ResolverUnbound:
+		lines = append(lines, subSection+"Unbound settings:")
+		for _, line := range s.Unbound.Lines() {
+			lines = append(lines, indent+line)
+		}
 	}
 
 	lines = append(lines, subSection+"Blacklisting settings:")
 	for _, line := range s.Blacklist.Lines(indent, subSection) {
 		lines = append(lines, indent+line)
 	}
+	for _, policy := range s.Policies {
+		lines = append(lines, subSection+"Blacklisting settings for policy "+policy.Name+":")
+		for _, line := range policy.Blacklist.Lines(indent, subSection) {
+			lines = append(lines, indent+line)
+		}
+	}
+	if s.Metrics.Enabled {
+		lines = append(lines, subSection+"Prometheus metrics: listening on "+s.Metrics.Address)
+	}
+	if s.Admin.Enabled {
+		adminLine := subSection + "Administration API: listening on " + s.Admin.Address
+		if s.Admin.Token == "" {
+			adminLine += " without authentication"
+		}
+		lines = append(lines, adminLine)
+	}
 	lines = append(lines, subSection+"Check DNS: "+checkDNS)
 	lines = append(lines, subSection+"Update: "+update)
 
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/qdm12/dns/pkg/doh"
+	"github.com/qdm12/dns/pkg/provider"
+	"github.com/qdm12/golibs/params"
+)
+
+func getDoHSettings(reader *reader, policies []Policy,
+	customs []provider.Provider) (settings doh.ServerSettings, err error) {
+	settings.Resolver.DoHProviders, err = getProviders(reader, customs)
+	if err != nil {
+		return settings, err
+	}
+	err = checkDoHProviders(settings.Resolver.DoHProviders)
+	if err != nil {
+		return settings, err
+	}
+	for _, policy := range policies {
+		err = checkDoHProviders(policy.Providers)
+		if err != nil {
+			return settings, fmt.Errorf("policy %s: %w", policy.Name, err)
+		}
+	}
+	settings.Resolver.Strategy, err = getUpstreamStrategy(reader)
+	if err != nil {
+		return settings, err
+	}
+	settings.Resolver.Race, err = getUpstreamRace(reader)
+	if err != nil {
+		return settings, err
+	}
+	// The same providers are used over DNS over TLS to resolve
+	// the DNS over HTTPS URL hostnames, except custom providers
+	// without DNS over TLS server.
+	settings.Resolver.SelfDNS.DoTProviders = filterDoTProviders(settings.Resolver.DoHProviders)
+	if len(settings.Resolver.SelfDNS.DoTProviders) == 0 {
+		reader.logger.Warn("none of the DNS over HTTPS providers has DNS over TLS servers, " +
+			"DNS over HTTPS URL hostnames are resolved using Cloudflare over DNS over TLS")
+	}
+	settings.Resolver.SelfDNS.IPv6, err = reader.env.OnOff("IPV6", params.Default("off"))
+	if err != nil {
+		return settings, err
+	}
+	settings.Port, err = reader.env.Port("LISTENINGPORT", params.Default("53"))
+	if err != nil {
+		return settings, err
+	}
+	settings.HTTP, err = getDoHListenerSettings(reader)
+	if err != nil {
+		return settings, err
+	}
+	settings.Cache, err = getCacheSettings(reader)
+	if err != nil {
+		return settings, err
+	}
+	settings.BlockResponse, err = getBlockResponseSettings(reader)
+	if err != nil {
+		return settings, err
+	}
+	settings.QueryLog, err = getQueryLogSettings(reader)
+	if err != nil {
+		return settings, err
+	}
+	settings.DNSSEC, err = getDNSSECSettings(reader)
+	if err != nil {
+		return settings, err
+	}
+	settings.Policies = dohPolicies(policies)
+	settings.SetDefaults()
+	return settings, nil
+}
+
+// getDoHListenerSettings obtains the settings to serve DNS over HTTPS
+// to clients, in addition to plaintext DNS. It is served over plain
+// HTTP if no certificate and key files are set.
+func getDoHListenerSettings(reader *reader) (settings doh.HTTPSettings, err error) {
+	settings.Enabled, err = reader.env.OnOff("DOH_LISTENER", params.Default("off"))
+	if err != nil {
+		return settings, err
+	}
+	settings.Port, err = reader.env.Port("DOH_LISTENER_PORT", params.Default("443"))
+	if err != nil {
+		return settings, err
+	}
+	settings.Path, err = reader.env.Get("DOH_LISTENER_PATH",
+		params.Default("/dns-query"), params.CaseSensitiveValue())
+	if err != nil {
+		return settings, err
+	}
+	settings.CertificateFile, settings.KeyFile, err = getListenerCertificate(reader)
+	if err != nil {
+		return settings, err
+	}
+	if (settings.CertificateFile == "") != (settings.KeyFile == "") {
+		return settings, fmt.Errorf("%w: for the DNS over HTTPS listener over TLS",
+			errListenerCertificateMissing)
+	}
+	return settings, nil
+}
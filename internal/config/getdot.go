@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/qdm12/dns/pkg/dot"
+	"github.com/qdm12/dns/pkg/provider"
+	"github.com/qdm12/golibs/params"
+)
+
+func getDoTSettings(reader *reader, policies []Policy,
+	customs []provider.Provider) (settings dot.ServerSettings, err error) {
+	settings.Resolver.DoTProviders, err = getProviders(reader, customs)
+	if err != nil {
+		return settings, err
+	}
+	err = checkDoTProviders(settings.Resolver.DoTProviders)
+	if err != nil {
+		return settings, err
+	}
+	for _, policy := range policies {
+		err = checkDoTProviders(policy.Providers)
+		if err != nil {
+			return settings, fmt.Errorf("policy %s: %w", policy.Name, err)
+		}
+	}
+	settings.Resolver.Strategy, err = getUpstreamStrategy(reader)
+	if err != nil {
+		return settings, err
+	}
+	settings.Resolver.Race, err = getUpstreamRace(reader)
+	if err != nil {
+		return settings, err
+	}
+	settings.Resolver.IPv6, err = reader.env.OnOff("IPV6", params.Default("off"))
+	if err != nil {
+		return settings, err
+	}
+	settings.Port, err = reader.env.Port("LISTENINGPORT", params.Default("53"))
+	if err != nil {
+		return settings, err
+	}
+	settings.TLS, err = getDoTListenerSettings(reader)
+	if err != nil {
+		return settings, err
+	}
+	settings.Cache, err = getCacheSettings(reader)
+	if err != nil {
+		return settings, err
+	}
+	settings.BlockResponse, err = getBlockResponseSettings(reader)
+	if err != nil {
+		return settings, err
+	}
+	settings.QueryLog, err = getQueryLogSettings(reader)
+	if err != nil {
+		return settings, err
+	}
+	settings.DNSSEC, err = getDNSSECSettings(reader)
+	if err != nil {
+		return settings, err
+	}
+	settings.Policies = dotPolicies(policies)
+	settings.SetDefaults()
+	return settings, nil
+}
+
+// getDoTListenerSettings obtains the settings to serve DNS over TLS
+// to clients, in addition to plaintext DNS.
+func getDoTListenerSettings(reader *reader) (settings dot.TLSSettings, err error) {
+	settings.Enabled, err = reader.env.OnOff("DOT_LISTENER", params.Default("off"))
+	if err != nil {
+		return settings, err
+	}
+	settings.Port, err = reader.env.Port("DOT_LISTENER_PORT", params.Default("853"))
+	if err != nil {
+		return settings, err
+	}
+	settings.CertificateFile, settings.KeyFile, err = getListenerCertificate(reader)
+	if err != nil {
+		return settings, err
+	}
+	if settings.Enabled && (settings.CertificateFile == "" || settings.KeyFile == "") {
+		return settings, fmt.Errorf("%w: for the DNS over TLS listener", errListenerCertificateMissing)
+	}
+	return settings, nil
+}
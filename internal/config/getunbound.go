@@ -1,13 +1,18 @@
 package config
 
 import (
+	"github.com/qdm12/dns/pkg/provider"
 	"github.com/qdm12/dns/pkg/unbound"
 	"github.com/qdm12/golibs/params"
 	"inet.af/netaddr"
 )
 
-func getUnboundSettings(reader *reader) (settings unbound.Settings, err error) {
-	settings.Providers, err = getProviders(reader)
+func getUnboundSettings(reader *reader, customs []provider.Provider) (settings unbound.Settings, err error) {
+	settings.Providers, err = getProviders(reader, customs)
+	if err != nil {
+		return settings, err
+	}
+	err = checkDoTProviders(settings.Providers)
 	if err != nil {
 		return settings, err
 	}
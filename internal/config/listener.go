@@ -0,0 +1,24 @@
+package config
+
+import (
+	"errors"
+
+	"github.com/qdm12/golibs/params"
+)
+
+var errListenerCertificateMissing = errors.New(
+	"LISTENER_CERTIFICATE_FILE and LISTENER_KEY_FILE must be set")
+
+// getListenerCertificate obtains the paths to the PEM encoded certificate
+// and key files used by the DNS over TLS and DNS over HTTPS listeners.
+func getListenerCertificate(reader *reader) (certificateFile, keyFile string, err error) {
+	certificateFile, err = reader.env.Get("LISTENER_CERTIFICATE_FILE", params.CaseSensitiveValue())
+	if err != nil {
+		return "", "", err
+	}
+	keyFile, err = reader.env.Get("LISTENER_KEY_FILE", params.CaseSensitiveValue())
+	if err != nil {
+		return "", "", err
+	}
+	return certificateFile, keyFile, nil
+}
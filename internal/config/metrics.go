@@ -0,0 +1,25 @@
+package config
+
+import (
+	"github.com/qdm12/golibs/params"
+)
+
+// MetricsSettings are settings to serve Prometheus metrics over HTTP.
+type MetricsSettings struct {
+	Enabled bool
+	Address string
+}
+
+// getMetricsSettings obtains the Prometheus metrics settings
+// for the built-in DNS servers.
+func getMetricsSettings(reader *reader) (settings MetricsSettings, err error) {
+	settings.Enabled, err = reader.env.OnOff("METRICS", params.Default("off"))
+	if err != nil {
+		return settings, err
+	}
+	settings.Address, err = reader.env.Get("METRICS_ADDRESS", params.Default(":9090"))
+	if err != nil {
+		return settings, err
+	}
+	return settings, nil
+}
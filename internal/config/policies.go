@@ -0,0 +1,117 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/qdm12/dns/pkg/blacklist"
+	"github.com/qdm12/dns/pkg/doh"
+	"github.com/qdm12/dns/pkg/dot"
+	"github.com/qdm12/dns/pkg/provider"
+	"inet.af/netaddr"
+)
+
+// Policy contains the settings of a filtering policy
+// for clients in one of the policy subnets.
+type Policy struct {
+	Name    string
+	Subnets []netaddr.IPPrefix
+	// Providers defaults to the PROVIDERS providers if empty.
+	Providers []provider.Provider
+	Blacklist blacklist.BuilderSettings
+}
+
+var (
+	errPolicyNameInvalid  = errors.New("policy name is invalid")
+	errPolicySubnetsEmpty = errors.New("policy has no subnet")
+	errPolicySubnetBad    = errors.New("policy subnet is invalid")
+	policyNameRegex       = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+)
+
+// getPolicies obtains the client policies from the comma separated list
+// of policy names for the environment variable POLICIES, and the
+// environment variables prefixed with POLICY_<NAME>_ for each policy.
+func getPolicies(reader *reader, customs []provider.Provider) (policies []Policy, err error) {
+	names, err := reader.env.CSV("POLICIES")
+	if err != nil {
+		return nil, err
+	}
+
+	policies = make([]Policy, len(names))
+	for i, name := range names {
+		policies[i], err = getPolicy(reader, name, customs)
+		if err != nil {
+			return nil, fmt.Errorf("policy %s: %w", name, err)
+		}
+	}
+	return policies, nil
+}
+
+func getPolicy(reader *reader, name string,
+	customs []provider.Provider) (policy Policy, err error) {
+	if !policyNameRegex.MatchString(name) {
+		return policy, fmt.Errorf("%w: %s", errPolicyNameInvalid, name)
+	}
+	policy.Name = name
+	prefix := "POLICY_" + strings.ToUpper(name) + "_"
+
+	values, err := reader.env.CSV(prefix + "SUBNETS")
+	if err != nil {
+		return policy, err
+	} else if len(values) == 0 {
+		return policy, fmt.Errorf("%w: %s must be set", errPolicySubnetsEmpty, prefix+"SUBNETS")
+	}
+	policy.Subnets = make([]netaddr.IPPrefix, len(values))
+	for i, value := range values {
+		policy.Subnets[i], err = netaddr.ParseIPPrefix(value)
+		if err != nil {
+			return policy, fmt.Errorf("%w: %s", errPolicySubnetBad, value)
+		}
+	}
+
+	words, err := reader.env.CSV(prefix + "PROVIDERS")
+	if err != nil {
+		return policy, err
+	}
+	policy.Providers, err = parseProviders(words, customs)
+	if err != nil {
+		return policy, err
+	}
+
+	policy.Blacklist, err = getBlacklistSettings(reader, prefix)
+	if err != nil {
+		return policy, err
+	}
+
+	return policy, nil
+}
+
+// dotPolicies converts the policies to DNS over TLS server policies.
+// Their blacklists are set later, once built.
+func dotPolicies(policies []Policy) (dotPolicies []dot.PolicySettings) {
+	dotPolicies = make([]dot.PolicySettings, len(policies))
+	for i, policy := range policies {
+		dotPolicies[i] = dot.PolicySettings{
+			Name:         policy.Name,
+			Subnets:      policy.Subnets,
+			DoTProviders: policy.Providers,
+		}
+	}
+	return dotPolicies
+}
+
+// dohPolicies converts the policies to DNS over HTTPS server policies.
+// Their blacklists are set later, once built.
+func dohPolicies(policies []Policy) (dohPolicies []doh.PolicySettings) {
+	dohPolicies = make([]doh.PolicySettings, len(policies))
+	for i, policy := range policies {
+		dohPolicies[i] = doh.PolicySettings{
+			Name:         policy.Name,
+			Subnets:      policy.Subnets,
+			DoHProviders: policy.Providers,
+		}
+	}
+	return dohPolicies
+}
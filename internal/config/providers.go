@@ -4,18 +4,24 @@ import (
 	"strings"
 
 	"github.com/qdm12/dns/pkg/provider"
+	"github.com/qdm12/dns/pkg/upstream"
 	"github.com/qdm12/golibs/params"
 )
 
 // getProviders obtains the DNS over TLS providers to use
 // from the environment variable PROVIDERS and PROVIDER for retro-compatibility.
-func getProviders(reader *reader) (providers []provider.Provider, err error) {
+// The custom providers given can be selected by name as well.
+func getProviders(reader *reader, customs []provider.Provider) (
+	providers []provider.Provider, err error) {
 	words, err := reader.env.CSV("PROVIDERS", params.Default("cloudflare"),
 		params.RetroKeys([]string{"PROVIDER"}, reader.onRetroActive))
 	if err != nil {
 		return nil, err
 	}
+	return parseProviders(words, customs)
+}
 
+func parseProviders(words []string, customs []provider.Provider) (providers []provider.Provider, err error) {
 	for _, word := range words {
 		// Retro compatibility
 		word = strings.ReplaceAll(word, ".", " ")
@@ -26,7 +32,7 @@ func getProviders(reader *reader) (providers []provider.Provider, err error) {
 			word = "cira private"
 		}
 
-		provider, err := provider.Parse(word)
+		provider, err := provider.Parse(word, customs...)
 		if err != nil {
 			return nil, err
 		}
@@ -35,3 +41,22 @@ func getProviders(reader *reader) (providers []provider.Provider, err error) {
 	}
 	return providers, nil
 }
+
+func getUpstreamStrategy(reader *reader) (strategy upstream.Strategy, err error) {
+	strategies := upstream.ListStrategies()
+	possibilities := make([]string, len(strategies))
+	for i := range strategies {
+		possibilities[i] = string(strategies[i])
+	}
+	s, err := reader.env.Inside("UPSTREAM_STRATEGY", possibilities,
+		params.Default(string(upstream.Random)))
+	if err != nil {
+		return "", err
+	}
+	return upstream.ParseStrategy(s)
+}
+
+func getUpstreamRace(reader *reader) (race int, err error) {
+	const maxRace = 8
+	return reader.env.IntRange("UPSTREAM_RACE", 1, maxRace, params.Default("1"))
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"github.com/qdm12/dns/pkg/querylog"
+	"github.com/qdm12/golibs/params"
+)
+
+// getQueryLogSettings obtains the query logging settings
+// for the built-in DNS servers.
+func getQueryLogSettings(reader *reader) (settings querylog.Settings, err error) {
+	settings.Stdout, err = reader.env.OnOff("QUERY_LOG_STDOUT", params.Default("off"))
+	if err != nil {
+		return settings, err
+	}
+
+	settings.File.Path, err = reader.env.Get("QUERY_LOG_FILE", params.CaseSensitiveValue())
+	if err != nil {
+		return settings, err
+	}
+
+	const maxRingSize = 1000000
+	settings.RingSize, err = reader.env.IntRange("QUERY_LOG_RING_SIZE", 0, maxRingSize, params.Default("0"))
+	if err != nil {
+		return settings, err
+	}
+
+	modes := querylog.ListAnonymizations()
+	possibilities := make([]string, len(modes))
+	for i := range modes {
+		possibilities[i] = string(modes[i])
+	}
+	anonymize, err := reader.env.Inside("QUERY_LOG_ANONYMIZE", possibilities,
+		params.Default(string(querylog.AnonymizeNone)))
+	if err != nil {
+		return settings, err
+	}
+	settings.Anonymize, err = querylog.ParseAnonymization(anonymize)
+	if err != nil {
+		return settings, err
+	}
+
+	settings.SetDefaults()
+	return settings, nil
+}
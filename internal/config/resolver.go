@@ -0,0 +1,22 @@
+package config
+
+import (
+	"github.com/qdm12/golibs/params"
+)
+
+const (
+	// ResolverDoT runs the built-in DNS over TLS server.
+	ResolverDoT = "dot"
+	// ResolverDoH runs the built-in DNS over HTTPS server.
+	ResolverDoH = "doh"
+	// ResolverUnbound configures and runs the Unbound binary.
+	ResolverUnbound = "unbound"
+)
+
+// getResolver obtains the resolver to run from the
+// environment variable RESOLVER.
+func getResolver(reader *reader) (resolver string, err error) {
+	return reader.env.Inside("RESOLVER",
+		[]string{ResolverDoT, ResolverDoH, ResolverUnbound},
+		params.Default(ResolverUnbound))
+}
@@ -4,25 +4,75 @@ import (
 	"time"
 
 	"github.com/qdm12/dns/pkg/blacklist"
+	"github.com/qdm12/dns/pkg/doh"
+	"github.com/qdm12/dns/pkg/dot"
 	"github.com/qdm12/dns/pkg/unbound"
 	"github.com/qdm12/golibs/params"
 )
 
 type Settings struct {
+	Resolver     string
+	DoT          dot.ServerSettings
+	DoH          doh.ServerSettings
 	Unbound      unbound.Settings
 	Blacklist    blacklist.BuilderSettings
+	Policies     []Policy
+	Metrics      MetricsSettings
+	Admin        AdminSettings
 	CheckDNS     bool
 	UpdatePeriod time.Duration
 }
 
 func (settings *Settings) get(reader *reader) (err error) {
-	settings.Unbound, err = getUnboundSettings(reader)
+	settings.Resolver, err = getResolver(reader)
+	if err != nil {
+		return err
+	}
+
+	// The providers catalog must be loaded before any provider is parsed.
+	err = loadProvidersCatalog(reader)
+	if err != nil {
+		return err
+	}
+
+	customs, err := getCustomProviders(reader)
+	if err != nil {
+		return err
+	}
+
+	switch settings.Resolver {
+	case ResolverDoT, ResolverDoH:
+		// Client policies are only supported by the built-in DNS servers.
+		settings.Policies, err = getPolicies(reader, customs)
+		if err != nil {
+			return err
+		}
+		// Metrics are only supported by the built-in DNS servers.
+		settings.Metrics, err = getMetricsSettings(reader)
+		if err != nil {
+			return err
+		}
+		// The administration API is only supported by the built-in DNS servers.
+		settings.Admin, err = getAdminSettings(reader)
+		if err != nil {
+			return err
+		}
+	}
+
+	switch settings.Resolver {
+	case ResolverDoT:
+		settings.DoT, err = getDoTSettings(reader, settings.Policies, customs)
+	case ResolverDoH:
+		settings.DoH, err = getDoHSettings(reader, settings.Policies, customs)
+	case ResolverUnbound:
+		settings.Unbound, err = getUnboundSettings(reader, customs)
+	}
 	if err != nil {
 		return err
 	}
 
 	// Blacklist building settings
-	settings.Blacklist, err = getBlacklistSettings(reader)
+	settings.Blacklist, err = getBlacklistSettings(reader, "")
 	if err != nil {
 		return err
 	}
@@ -7,10 +7,13 @@ import (
 	"inet.af/netaddr"
 )
 
+// mapBased is a BlackLister matching hostnames exactly.
+// Use NewTree to also block subdomains of blocked hostnames.
 type mapBased struct {
-	fqdnHostnames map[string]struct{}
-	ips           map[netaddr.IP]struct{}
-	ipPrefixes    []netaddr.IPPrefix
+	fqdnHostnames        map[string]struct{}
+	allowedFqdnHostnames map[string]struct{}
+	ips                  map[netaddr.IP]struct{}
+	ipPrefixes           []netaddr.IPPrefix
 }
 
 func NewMap(settings Settings) BlackLister {
@@ -19,21 +22,30 @@ func NewMap(settings Settings) BlackLister {
 		fqdnHostnamesSet[fqdnHostname] = struct{}{}
 	}
 
+	allowedFqdnHostnamesSet := make(map[string]struct{}, len(settings.AllowedFqdnHostnames))
+	for _, fqdnHostname := range settings.AllowedFqdnHostnames {
+		allowedFqdnHostnamesSet[fqdnHostname] = struct{}{}
+	}
+
 	ipsSet := make(map[netaddr.IP]struct{}, len(settings.IPs))
 	for _, ip := range settings.IPs {
 		ipsSet[ip] = struct{}{}
 	}
 
 	return &mapBased{
-		fqdnHostnames: fqdnHostnamesSet,
-		ips:           ipsSet,
-		ipPrefixes:    settings.IPPrefixes,
+		fqdnHostnames:        fqdnHostnamesSet,
+		allowedFqdnHostnames: allowedFqdnHostnamesSet,
+		ips:                  ipsSet,
+		ipPrefixes:           settings.IPPrefixes,
 	}
 }
 
 func (m *mapBased) FilterRequest(request *dns.Msg) (blocked bool) {
 	for _, question := range request.Question {
 		fqdnHostname := question.Name
+		if _, allowed := m.allowedFqdnHostnames[fqdnHostname]; allowed {
+			continue
+		}
 		if _, blocked := m.fqdnHostnames[fqdnHostname]; blocked {
 			return blocked
 		}
@@ -42,17 +54,22 @@ func (m *mapBased) FilterRequest(request *dns.Msg) (blocked bool) {
 }
 
 func (m *mapBased) FilterResponse(response *dns.Msg) (blocked bool) {
+	return isResponseIPBlocked(response, m.ips, m.ipPrefixes)
+}
+
+func isResponseIPBlocked(response *dns.Msg, ips map[netaddr.IP]struct{},
+	ipPrefixes []netaddr.IPPrefix) (blocked bool) {
 	for _, rr := range response.Answer {
 		// only filter A and AAAA responses for now
 		switch rr.Header().Rrtype {
 		case dns.TypeA:
 			record := rr.(*dns.A)
-			if blocked := m.isIPBlocked(record.A); blocked {
+			if blocked := isIPBlocked(record.A, ips, ipPrefixes); blocked {
 				return blocked
 			}
 		case dns.TypeAAAA:
 			record := rr.(*dns.AAAA)
-			if blocked := m.isIPBlocked(record.AAAA); blocked {
+			if blocked := isIPBlocked(record.AAAA, ips, ipPrefixes); blocked {
 				return blocked
 			}
 		}
@@ -60,17 +77,18 @@ func (m *mapBased) FilterResponse(response *dns.Msg) (blocked bool) {
 	return false
 }
 
-func (m *mapBased) isIPBlocked(ip net.IP) (blocked bool) {
+func isIPBlocked(ip net.IP, ips map[netaddr.IP]struct{},
+	ipPrefixes []netaddr.IPPrefix) (blocked bool) {
 	netaddrIP, ok := netaddr.FromStdIP(ip)
 	if !ok {
 		return true
 	}
 
-	if _, blocked := m.ips[netaddrIP]; blocked {
+	if _, blocked := ips[netaddrIP]; blocked {
 		return blocked
 	}
 
-	for _, ipPrefix := range m.ipPrefixes {
+	for _, ipPrefix := range ipPrefixes {
 		if ipPrefix.Contains(netaddrIP) {
 			return true
 		}
@@ -0,0 +1,180 @@
+package blacklist
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+	"inet.af/netaddr"
+)
+
+// ResponseMode is the style of DNS response sent back
+// to the client for a blocked query.
+type ResponseMode string
+
+const (
+	// ResponseRefused answers blocked queries with the REFUSED rcode.
+	ResponseRefused ResponseMode = "refused"
+	// ResponseNXDomain answers blocked queries with the NXDOMAIN rcode.
+	ResponseNXDomain ResponseMode = "nxdomain"
+	// ResponseNoData answers blocked queries with the NOERROR rcode
+	// and no answer record.
+	ResponseNoData ResponseMode = "nodata"
+	// ResponseSinkhole answers blocked A and AAAA queries with the
+	// sinkhole IP address, and other blocked queries as ResponseNoData.
+	ResponseSinkhole ResponseMode = "sinkhole"
+)
+
+func ListResponseModes() (modes []ResponseMode) {
+	return []ResponseMode{
+		ResponseRefused,
+		ResponseNXDomain,
+		ResponseNoData,
+		ResponseSinkhole,
+	}
+}
+
+var ErrParseResponseMode = errors.New("cannot parse response mode")
+
+func ParseResponseMode(s string) (mode ResponseMode, err error) {
+	for _, M := range ListResponseModes() {
+		if strings.EqualFold(string(M), s) {
+			return M, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q is unknown", ErrParseResponseMode, s)
+}
+
+// ResponseSettings are settings to build the DNS response
+// sent back to the client for a blocked query or response.
+type ResponseSettings struct {
+	Mode ResponseMode
+	// SinkholeIPv4 is the IPv4 address answered to blocked A queries
+	// for the sinkhole mode, and defaults to 0.0.0.0.
+	SinkholeIPv4 netaddr.IP
+	// SinkholeIPv6 is the IPv6 address answered to blocked AAAA queries
+	// for the sinkhole mode, and defaults to ::.
+	SinkholeIPv6 netaddr.IP
+	// TTL is the time to live in seconds of the sinkhole answers,
+	// and of the negative answers for the nxdomain and nodata modes.
+	// It defaults to 3600.
+	TTL uint32
+}
+
+func (s *ResponseSettings) SetDefaults() {
+	if string(s.Mode) == "" {
+		s.Mode = ResponseRefused
+	}
+
+	if s.SinkholeIPv4.IsZero() {
+		s.SinkholeIPv4 = netaddr.IPv4(0, 0, 0, 0)
+	}
+
+	if s.SinkholeIPv6.IsZero() {
+		s.SinkholeIPv6 = netaddr.IPv6Unspecified()
+	}
+
+	if s.TTL == 0 {
+		const defaultTTL = 3600
+		s.TTL = defaultTTL
+	}
+}
+
+// BlockedResponse returns the DNS response to send back
+// to the client for the blocked request given.
+func (s *ResponseSettings) BlockedResponse(request *dns.Msg) (response *dns.Msg) {
+	switch s.Mode {
+	case ResponseNXDomain:
+		response = new(dns.Msg).SetRcode(request, dns.RcodeNameError)
+		s.addSOA(response)
+		return response
+	case ResponseNoData:
+		response = new(dns.Msg).SetReply(request)
+		s.addSOA(response)
+		return response
+	case ResponseSinkhole:
+		response = new(dns.Msg).SetReply(request)
+		for _, question := range request.Question {
+			header := dns.RR_Header{
+				Name:   question.Name,
+				Rrtype: question.Qtype,
+				Class:  question.Qclass,
+				Ttl:    s.TTL,
+			}
+			switch question.Qtype {
+			case dns.TypeA:
+				response.Answer = append(response.Answer, &dns.A{
+					Hdr: header,
+					A:   s.SinkholeIPv4.IPAddr().IP,
+				})
+			case dns.TypeAAAA:
+				response.Answer = append(response.Answer, &dns.AAAA{
+					Hdr:  header,
+					AAAA: s.SinkholeIPv6.IPAddr().IP,
+				})
+			}
+		}
+		if len(response.Answer) == 0 {
+			s.addSOA(response)
+		}
+		return response
+	default:
+		return new(dns.Msg).SetRcode(request, dns.RcodeRefused)
+	}
+}
+
+// addSOA adds a synthetic SOA record to the authority section of the
+// negative response given, so clients can cache it for the TTL set,
+// as described in RFC 2308.
+func (s *ResponseSettings) addSOA(response *dns.Msg) {
+	if len(response.Question) == 0 {
+		return
+	}
+	const (
+		soaNS      = "blocked.invalid."
+		soaMbox    = "hostmaster.blocked.invalid."
+		soaRefresh = 3600
+		soaRetry   = 600
+		soaExpire  = 86400
+	)
+	response.Ns = append(response.Ns, &dns.SOA{
+		Hdr: dns.RR_Header{
+			Name:   response.Question[0].Name,
+			Rrtype: dns.TypeSOA,
+			Class:  dns.ClassINET,
+			Ttl:    s.TTL,
+		},
+		Ns:      soaNS,
+		Mbox:    soaMbox,
+		Serial:  1,
+		Refresh: soaRefresh,
+		Retry:   soaRetry,
+		Expire:  soaExpire,
+		Minttl:  s.TTL,
+	})
+}
+
+func (s *ResponseSettings) String() string {
+	const (
+		subSection = " |--"
+		indent     = "    " // used if lines already contain the subSection
+	)
+	return strings.Join(s.Lines(indent, subSection), "\n")
+}
+
+func (s *ResponseSettings) Lines(indent, subSection string) (lines []string) {
+	lines = append(lines, subSection+"Mode: "+string(s.Mode))
+
+	if s.Mode == ResponseSinkhole {
+		lines = append(lines, subSection+"Sinkhole IPv4 address: "+s.SinkholeIPv4.String())
+		lines = append(lines, subSection+"Sinkhole IPv6 address: "+s.SinkholeIPv6.String())
+	}
+
+	if s.Mode != ResponseRefused {
+		lines = append(lines, subSection+"TTL: "+strconv.Itoa(int(s.TTL))+"s")
+	}
+
+	return lines
+}
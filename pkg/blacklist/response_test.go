@@ -0,0 +1,149 @@
+package blacklist
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"inet.af/netaddr"
+)
+
+func Test_ParseResponseMode(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		s    string
+		mode ResponseMode
+		err  error
+	}{
+		"empty": {
+			err: errors.New(`cannot parse response mode: "" is unknown`),
+		},
+		"unknown": {
+			s:   "servfail",
+			err: errors.New(`cannot parse response mode: "servfail" is unknown`),
+		},
+		"nxdomain": {
+			s:    "nxdomain",
+			mode: ResponseNXDomain,
+		},
+		"case insensitive": {
+			s:    "SinkHole",
+			mode: ResponseSinkhole,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mode, err := ParseResponseMode(testCase.s)
+
+			if testCase.err != nil {
+				assert.EqualError(t, err, testCase.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, testCase.mode, mode)
+		})
+	}
+}
+
+func Test_ResponseSettings_BlockedResponse(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		settings ResponseSettings
+		qtype    uint16
+		rcode    int
+		answer   []dns.RR
+		soaTTL   uint32 // 0 if no SOA is expected
+	}{
+		"default refused": {
+			qtype: dns.TypeA,
+			rcode: dns.RcodeRefused,
+		},
+		"nxdomain": {
+			settings: ResponseSettings{Mode: ResponseNXDomain},
+			qtype:    dns.TypeA,
+			rcode:    dns.RcodeNameError,
+			soaTTL:   3600,
+		},
+		"nodata": {
+			settings: ResponseSettings{Mode: ResponseNoData, TTL: 60},
+			qtype:    dns.TypeA,
+			rcode:    dns.RcodeSuccess,
+			soaTTL:   60,
+		},
+		"sinkhole A": {
+			settings: ResponseSettings{
+				Mode:         ResponseSinkhole,
+				SinkholeIPv4: netaddr.IPv4(1, 2, 3, 4),
+				TTL:          60,
+			},
+			qtype: dns.TypeA,
+			rcode: dns.RcodeSuccess,
+			answer: []dns.RR{&dns.A{
+				Hdr: dns.RR_Header{
+					Name: "github.com.", Rrtype: dns.TypeA,
+					Class: dns.ClassINET, Ttl: 60,
+				},
+				A: net.IP{1, 2, 3, 4},
+			}},
+		},
+		"sinkhole AAAA": {
+			settings: ResponseSettings{
+				Mode: ResponseSinkhole,
+				TTL:  60,
+			},
+			qtype: dns.TypeAAAA,
+			rcode: dns.RcodeSuccess,
+			answer: []dns.RR{&dns.AAAA{
+				Hdr: dns.RR_Header{
+					Name: "github.com.", Rrtype: dns.TypeAAAA,
+					Class: dns.ClassINET, Ttl: 60,
+				},
+				AAAA: net.IPv6unspecified,
+			}},
+		},
+		"sinkhole MX": {
+			settings: ResponseSettings{Mode: ResponseSinkhole},
+			qtype:    dns.TypeMX,
+			rcode:    dns.RcodeSuccess,
+			soaTTL:   3600,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			settings := testCase.settings
+			settings.SetDefaults()
+			request := new(dns.Msg).SetQuestion("github.com.", testCase.qtype)
+
+			response := settings.BlockedResponse(request)
+
+			assert.Equal(t, request.Id, response.Id)
+			assert.True(t, response.Response)
+			assert.Equal(t, testCase.rcode, response.Rcode)
+			assert.Equal(t, testCase.answer, response.Answer)
+
+			if testCase.soaTTL == 0 {
+				assert.Empty(t, response.Ns)
+				return
+			}
+			require.Len(t, response.Ns, 1)
+			soa, ok := response.Ns[0].(*dns.SOA)
+			require.True(t, ok)
+			assert.Equal(t, "github.com.", soa.Hdr.Name)
+			assert.Equal(t, testCase.soaTTL, soa.Hdr.Ttl)
+			assert.Equal(t, testCase.soaTTL, soa.Minttl)
+		})
+	}
+}
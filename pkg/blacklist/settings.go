@@ -10,9 +10,10 @@ import (
 )
 
 type Settings struct {
-	FqdnHostnames []string
-	IPs           []netaddr.IP
-	IPPrefixes    []netaddr.IPPrefix
+	FqdnHostnames        []string
+	AllowedFqdnHostnames []string
+	IPs                  []netaddr.IP
+	IPPrefixes           []netaddr.IPPrefix
 }
 
 // BlockHostnames transforms the slice of hostnames given to
@@ -24,6 +25,15 @@ func (s *Settings) BlockHostnames(hostnames []string) {
 	}
 }
 
+// AllowHostnames transforms the slice of hostnames given to
+// FQDN hostnames and sets these as allowed to the settings.
+func (s *Settings) AllowHostnames(hostnames []string) {
+	s.AllowedFqdnHostnames = make([]string, len(hostnames))
+	for i := range hostnames {
+		s.AllowedFqdnHostnames[i] = dns.Fqdn(hostnames[i])
+	}
+}
+
 // AddBlockHostnames transforms the slice of hostnames given to
 // FQDN hostnames and adds the new hostnames to the settings,
 // removing any duplicate.
@@ -76,5 +86,10 @@ func (s *Settings) Lines(indent, subSection string) (lines []string) {
 			strconv.Itoa(len(s.FqdnHostnames)))
 	}
 
+	if len(s.AllowedFqdnHostnames) > 0 {
+		lines = append(lines, subSection+"Hostnames allowed: "+
+			strconv.Itoa(len(s.AllowedFqdnHostnames)))
+	}
+
 	return lines
 }
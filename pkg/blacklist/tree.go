@@ -0,0 +1,132 @@
+package blacklist
+
+import (
+	"strings"
+
+	"github.com/miekg/dns"
+	"inet.af/netaddr"
+)
+
+// treeBased is a BlackLister matching hostnames using a tree of
+// domain labels, starting from the top level domain. A hostname
+// such as "example.com" blocks itself and all its subdomains,
+// and a wildcard hostname such as "*.example.com" only blocks the
+// subdomains of example.com. Allowed hostnames follow the same
+// rules, and the deepest matching rule decides if a hostname is
+// blocked or not, such that "ads.example.com" can be allowed
+// even if "example.com" is blocked, and vice versa.
+type treeBased struct {
+	root       *domainNode
+	ips        map[netaddr.IP]struct{}
+	ipPrefixes []netaddr.IPPrefix
+}
+
+func NewTree(settings Settings) BlackLister {
+	root := newDomainNode()
+	for _, fqdnHostname := range settings.FqdnHostnames {
+		root.insert(fqdnHostname, actionBlock)
+	}
+	// Allowed hostnames are inserted last so they take precedence
+	// over blocked hostnames at the same label depth.
+	for _, fqdnHostname := range settings.AllowedFqdnHostnames {
+		root.insert(fqdnHostname, actionAllow)
+	}
+
+	ipsSet := make(map[netaddr.IP]struct{}, len(settings.IPs))
+	for _, ip := range settings.IPs {
+		ipsSet[ip] = struct{}{}
+	}
+
+	return &treeBased{
+		root:       root,
+		ips:        ipsSet,
+		ipPrefixes: settings.IPPrefixes,
+	}
+}
+
+func (t *treeBased) FilterRequest(request *dns.Msg) (blocked bool) {
+	for _, question := range request.Question {
+		if t.root.match(question.Name) == actionBlock {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *treeBased) FilterResponse(response *dns.Msg) (blocked bool) {
+	return isResponseIPBlocked(response, t.ips, t.ipPrefixes)
+}
+
+type action uint8
+
+const (
+	actionNone action = iota
+	actionBlock
+	actionAllow
+)
+
+type domainNode struct {
+	children map[string]*domainNode
+	// action applies to the node domain and all its subdomains.
+	action action
+	// wildcardAction applies only to the subdomains of the node domain.
+	wildcardAction action
+}
+
+func newDomainNode() *domainNode {
+	return &domainNode{
+		children: make(map[string]*domainNode),
+	}
+}
+
+// insert inserts the hostname given in the tree with the action given.
+// The hostname can start with the wildcard label "*" to have the action
+// only apply to the subdomains of the rest of the hostname.
+func (n *domainNode) insert(hostname string, a action) {
+	labels := dns.SplitDomainName(strings.ToLower(hostname))
+	wildcard := len(labels) > 0 && labels[0] == "*"
+	if wildcard {
+		labels = labels[1:]
+	}
+
+	node := n
+	for i := len(labels) - 1; i >= 0; i-- {
+		child, ok := node.children[labels[i]]
+		if !ok {
+			child = newDomainNode()
+			node.children[labels[i]] = child
+		}
+		node = child
+	}
+
+	if wildcard {
+		node.wildcardAction = a
+	} else {
+		node.action = a
+	}
+}
+
+// match returns the action of the deepest rule matching the hostname.
+func (n *domainNode) match(hostname string) (a action) {
+	labels := dns.SplitDomainName(strings.ToLower(hostname))
+
+	a = n.action
+	node := n
+	for i := len(labels) - 1; i >= 0; i-- {
+		// the hostname is a subdomain of the current node domain
+		if node.wildcardAction != actionNone {
+			a = node.wildcardAction
+		}
+
+		child, ok := node.children[labels[i]]
+		if !ok {
+			break
+		}
+		node = child
+
+		if node.action != actionNone {
+			a = node.action
+		}
+	}
+	return a
+}
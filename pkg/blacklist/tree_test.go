@@ -0,0 +1,83 @@
+package blacklist
+
+import (
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"inet.af/netaddr"
+)
+
+func Test_treeBased(t *testing.T) {
+	t.Parallel()
+
+	settings := Settings{
+		IPs: []netaddr.IP{
+			netaddr.IPv4(2, 2, 2, 2),
+		},
+	}
+	settings.BlockHostnames([]string{
+		"github.com",
+		"*.tracker.example",
+		"doubleclick.net",
+		"*.allowed.doubleclick.net",
+	})
+	settings.AllowHostnames([]string{
+		"ok.doubleclick.net",
+		"blocked.ok.doubleclick.net",
+		"allowed.doubleclick.net",
+	})
+
+	blacklister := NewTree(settings)
+
+	testCases := map[string]struct {
+		hostname string
+		blocked  bool
+	}{
+		"exact match":                 {hostname: "github.com.", blocked: true},
+		"exact match uppercase":       {hostname: "GitHub.COM.", blocked: true},
+		"subdomain":                   {hostname: "api.github.com.", blocked: true},
+		"parent not blocked":          {hostname: "com.", blocked: false},
+		"sibling not blocked":         {hostname: "gitlab.com.", blocked: false},
+		"suffix without dot":          {hostname: "notgithub.com.", blocked: false},
+		"wildcard apex":               {hostname: "tracker.example.", blocked: false},
+		"wildcard subdomain":          {hostname: "a.tracker.example.", blocked: true},
+		"wildcard deep subdomain":     {hostname: "a.b.tracker.example.", blocked: true},
+		"allowed exception":           {hostname: "ok.doubleclick.net.", blocked: false},
+		"allowed exception subdomain": {hostname: "x.ok.doubleclick.net.", blocked: false},
+		"allowed in allowed":          {hostname: "blocked.ok.doubleclick.net.", blocked: false},
+		"blocked around exception":    {hostname: "ad.doubleclick.net.", blocked: true},
+		"allowed apex of wildcard":    {hostname: "allowed.doubleclick.net.", blocked: false},
+		"wildcard under allowed":      {hostname: "x.allowed.doubleclick.net.", blocked: true},
+		"root":                        {hostname: ".", blocked: false},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			request := new(dns.Msg).SetQuestion(testCase.hostname, dns.TypeA)
+			blocked := blacklister.FilterRequest(request)
+			assert.Equal(t, testCase.blocked, blocked)
+		})
+	}
+
+	assert.True(t, blacklister.FilterResponse(&dns.Msg{
+		Answer: []dns.RR{
+			&dns.A{
+				Hdr: dns.RR_Header{Rrtype: dns.TypeA},
+				A:   net.IP{2, 2, 2, 2},
+			},
+		},
+	}))
+	assert.False(t, blacklister.FilterResponse(&dns.Msg{
+		Answer: []dns.RR{
+			&dns.A{
+				Hdr: dns.RR_Header{Rrtype: dns.TypeA},
+				A:   net.IP{7, 6, 5, 4},
+			},
+		},
+	}))
+}
@@ -1,6 +1,8 @@
 package cache
 
 import (
+	"io"
+
 	"github.com/miekg/dns"
 	"github.com/qdm12/dns/pkg/cache/lru"
 )
@@ -9,9 +11,36 @@ import (
 
 type Cache interface {
 	Add(request, response *dns.Msg)
-	Get(request *dns.Msg) (response *dns.Msg)
+	// Get returns the cached response for the request, or nil if
+	// there is none. prefetch is true if the response is about to
+	// expire and should be refreshed from upstream by the caller.
+	Get(request *dns.Msg) (response *dns.Msg, prefetch bool)
+	// GetStale returns an expired cached response for the request
+	// if serving stale responses is enabled, or nil otherwise.
+	// It is meant to be used when the upstream exchange fails.
+	GetStale(request *dns.Msg) (response *dns.Msg)
+	// Save writes a snapshot of the cache to the writer,
+	// with each response in wire format and its expiry time.
+	Save(w io.Writer) (err error)
+	// Load loads cache entries from a snapshot written with
+	// Save, dropping entries which expired in the meantime.
+	Load(r io.Reader) (err error)
+	// Remove removes all the cached responses for the name and,
+	// if subdomains is true, for all its subdomains. It returns
+	// the number of cached responses removed.
+	Remove(name string, subdomains bool) (removed int)
+	// Flush removes all the cached responses.
+	Flush()
+	// Len returns the number of cached responses.
+	Len() int
+	// Range calls f for each cached response until f returns false.
+	// f must not call any method of the cache.
+	Range(f func(entry Entry) bool)
 }
 
+// Entry contains information on a cached response.
+type Entry = lru.Entry
+
 // New creates a new cache object except when the cache type
 // is set to Disabled. In this case it returns a nil Cache.
 func New(settings Settings) Cache {
@@ -19,6 +48,8 @@ func New(settings Settings) Cache {
 	switch settings.Type {
 	case LRU:
 		return lru.New(settings.LRU)
+	case ShardedLRU:
+		return lru.NewSharded(settings.LRU, settings.Shards)
 	case Disabled:
 		return nil
 	default: // coding error as an end user should use ParseType
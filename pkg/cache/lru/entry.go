@@ -1,30 +1,283 @@
 package lru
 
 import (
+	"net"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/miekg/dns"
 )
 
 type entry struct {
-	key      string // from the DNS request
-	expUnix  int64  // from the DNS response
-	response *dns.Msg
+	key         string // from the DNS request
+	addedUnix   int64  // time the response was cached at
+	expUnix     int64  // from the DNS response
+	response    *dns.Msg
+	size        int  // wire format length of the response
+	prefetching bool // true once a prefetch was signaled
 }
 
+// makeKey returns the cache key for the request, made of its first
+// question lowercased name, type and class, and its DNSSEC OK and
+// checking disabled flags, since these all change the response content.
+// Responses to requests with an EDNS0 client subnet are cached under
+// this key suffixed with their scope prefix, see responseKey.
 func makeKey(request *dns.Msg) (key string) {
 	question := request.Question[0]
-	key = question.Name + "|" + strconv.Itoa(int(question.Qtype)) + "|" + strconv.Itoa(int(question.Qclass))
+	key = strings.ToLower(question.Name) + "|" + strconv.Itoa(int(question.Qtype)) +
+		"|" + strconv.Itoa(int(question.Qclass)) + "|"
+
+	opt := request.IsEdns0()
+	if opt != nil && opt.Do() {
+		key += "do"
+	}
+	if request.CheckingDisabled {
+		key += "cd"
+	}
+
+	return key
+}
+
+// responseKey returns the cache key to cache the response to the
+// request under, given the request key. Following RFC 7871 section 7.3,
+// if the request has an EDNS0 client subnet, the key is suffixed with
+// the client subnet prefix of the response scope prefix length, capped
+// to the request source prefix length. A response without client subnet
+// or with a scope prefix length of 0 is valid for all clients, and is
+// cached under the request key.
+func responseKey(key string, request, response *dns.Msg) string {
+	subnet := getClientSubnet(request.IsEdns0())
+	if subnet == nil {
+		return key
+	}
+
+	var scope uint8
+	if responseSubnet := getClientSubnet(response.IsEdns0()); responseSubnet != nil {
+		scope = responseSubnet.SourceScope
+	}
+	if scope > subnet.SourceNetmask {
+		scope = subnet.SourceNetmask
+	}
+
+	if scope == 0 {
+		return key
+	}
+	return subnetKey(key, subnet, scope)
+}
+
+// lookupKeys returns the cache keys to look up for the request,
+// given the request key, from the most to the least specific one.
+// If the request has an EDNS0 client subnet, these are the request
+// key suffixed with the client subnet prefix for each prefix length
+// from the request source prefix length down to 1, followed by the
+// request key for responses valid for all clients.
+func lookupKeys(key string, request *dns.Msg) (keys []string) {
+	subnet := getClientSubnet(request.IsEdns0())
+	if subnet == nil || subnet.SourceNetmask == 0 {
+		return []string{key}
+	}
+
+	keys = make([]string, 0, int(subnet.SourceNetmask)+1)
+	for length := subnet.SourceNetmask; length > 0; length-- {
+		subnetKey := subnetKey(key, subnet, length)
+		if subnetKey == key {
+			break // invalid client subnet
+		}
+		keys = append(keys, subnetKey)
+	}
+	return append(keys, key)
+}
+
+// requestKey returns the request key of the cache key given,
+// removing its client subnet prefix suffix if any.
+func requestKey(key string) string {
+	i := strings.LastIndexByte(key, '|')
+	if i >= 0 && strings.IndexByte(key[i:], '/') >= 0 {
+		return key[:i]
+	}
 	return key
 }
 
-func getExpUnix(response *dns.Msg, nowUnix int64) (expUnix int64) {
-	secondsLeft := ^uint32(0)
+// subnetKey returns the key given suffixed with the client subnet
+// prefix of the length given, or the key unchanged if the client
+// subnet is invalid.
+func subnetKey(key string, subnet *dns.EDNS0_SUBNET, length uint8) string {
+	const familyIPv4 = 1
+	ip, bits := subnet.Address.To16(), net.IPv6len*8 //nolint:gomnd
+	if subnet.Family == familyIPv4 {
+		ip, bits = subnet.Address.To4(), net.IPv4len*8 //nolint:gomnd
+	}
+	mask := net.CIDRMask(int(length), bits)
+	if ip == nil || mask == nil {
+		return key
+	}
+	prefix := net.IPNet{IP: ip.Mask(mask), Mask: mask}
+	return key + "|" + prefix.String()
+}
+
+func getClientSubnet(opt *dns.OPT) (subnet *dns.EDNS0_SUBNET) {
+	if opt == nil {
+		return nil
+	}
+	for _, option := range opt.Option {
+		if subnet, ok := option.(*dns.EDNS0_SUBNET); ok {
+			return subnet
+		}
+	}
+	return nil
+}
+
+// getTTL returns the number of seconds to cache the response for,
+// and cache as false if the response should not be cached.
+// Negative responses are cached following RFC 2308, using the
+// minimum of the SOA record TTL and minimum field, capped to
+// negativeMaxTTL. Negative responses without SOA record are not
+// cached, and SERVFAIL responses are cached for servFailTTL.
+func getTTL(response *dns.Msg, negativeMaxTTL, servFailTTL uint32) (
+	ttl uint32, cache bool) {
+	switch response.Rcode {
+	case dns.RcodeSuccess, dns.RcodeNameError:
+	case dns.RcodeServerFailure:
+		return servFailTTL, servFailTTL > 0
+	default:
+		return 0, false
+	}
+
+	ttl = ^uint32(0)
 	for _, rr := range response.Answer {
-		ttl := rr.Header().Ttl
-		if ttl < secondsLeft {
-			secondsLeft = ttl
+		if rr.Header().Ttl < ttl {
+			ttl = rr.Header().Ttl
 		}
 	}
-	return nowUnix + int64(secondsLeft)
+
+	negative := response.Rcode == dns.RcodeNameError || len(response.Answer) == 0
+	if !negative {
+		return ttl, true
+	}
+
+	soa := getSOA(response)
+	if soa == nil {
+		return 0, false
+	}
+
+	negativeTTL := soa.Hdr.Ttl
+	if soa.Minttl < negativeTTL {
+		negativeTTL = soa.Minttl
+	}
+	if negativeTTL > negativeMaxTTL {
+		negativeTTL = negativeMaxTTL
+	}
+	if negativeTTL < ttl {
+		ttl = negativeTTL
+	}
+	return ttl, true
+}
+
+func getSOA(response *dns.Msg) (soa *dns.SOA) {
+	for _, rr := range response.Ns {
+		if soa, ok := rr.(*dns.SOA); ok {
+			return soa
+		}
+	}
+	return nil
+}
+
+// clampAnswerTTLs clamps the TTL of each answer record of a positive
+// response between minTTL and maxTTL, with maxTTL taking precedence.
+func clampAnswerTTLs(response *dns.Msg, minTTL, maxTTL uint32) {
+	if response.Rcode != dns.RcodeSuccess {
+		return
+	}
+
+	for _, rr := range response.Answer {
+		header := rr.Header()
+		if header.Ttl < minTTL {
+			header.Ttl = minTTL
+		}
+		if header.Ttl > maxTTL {
+			header.Ttl = maxTTL
+		}
+	}
+}
+
+// capSOATTL lowers the TTL of the authority SOA record to the
+// TTL the response is cached for, so clients do not cache a
+// negative response longer than it is cached for.
+func capSOATTL(response *dns.Msg, ttl uint32) {
+	soa := getSOA(response)
+	if soa != nil && soa.Hdr.Ttl > ttl {
+		soa.Hdr.Ttl = ttl
+	}
+}
+
+// decrementTTLs decrements the TTL of each record of the response
+// by the number of seconds elapsed since the response was cached,
+// down to 0. OPT pseudo records are left untouched since their
+// TTL field holds the extended rcode and flags.
+func decrementTTLs(response *dns.Msg, elapsed uint32) {
+	for _, section := range [][]dns.RR{response.Answer, response.Ns, response.Extra} {
+		for _, rr := range section {
+			header := rr.Header()
+			if header.Rrtype == dns.TypeOPT {
+				continue
+			}
+
+			if header.Ttl > elapsed {
+				header.Ttl -= elapsed
+			} else {
+				header.Ttl = 0
+			}
+		}
+	}
+}
+
+// setTTLs sets the TTL of each record of the response to the
+// ttl given. OPT pseudo records are left untouched.
+func setTTLs(response *dns.Msg, ttl uint32) {
+	for _, section := range [][]dns.RR{response.Answer, response.Ns, response.Extra} {
+		for _, rr := range section {
+			header := rr.Header()
+			if header.Rrtype != dns.TypeOPT {
+				header.Ttl = ttl
+			}
+		}
+	}
+}
+
+// Entry contains information on a cached response.
+type Entry struct {
+	Name   string    `json:"name"`
+	Type   string    `json:"type"`
+	Rcode  string    `json:"rcode"`
+	Expiry time.Time `json:"expiry"`
+	Size   int       `json:"size"`
+}
+
+func (e *entry) export() (exported Entry) {
+	exported = Entry{
+		Rcode:  dns.RcodeToString[e.response.Rcode],
+		Expiry: time.Unix(e.expUnix, 0),
+		Size:   e.size,
+	}
+	if len(e.response.Question) > 0 {
+		question := e.response.Question[0]
+		exported.Name = strings.ToLower(question.Name)
+		exported.Type = dns.TypeToString[question.Qtype]
+	}
+	return exported
+}
+
+// matches returns true if the entry question name is the name
+// given or, if subdomains is true, one of its subdomains.
+// The name given must be lowercased and fully qualified.
+func (e *entry) matches(name string, subdomains bool) bool {
+	if len(e.response.Question) == 0 {
+		return false
+	}
+	entryName := strings.ToLower(e.response.Question[0].Name)
+	if entryName == name {
+		return true
+	}
+	return subdomains && (name == "." || strings.HasSuffix(entryName, "."+name))
 }
@@ -0,0 +1,288 @@
+package lru
+
+import (
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_getTTL(t *testing.T) {
+	t.Parallel()
+
+	soa := &dns.SOA{
+		Hdr:    dns.RR_Header{Rrtype: dns.TypeSOA, Ttl: 900},
+		Minttl: 600,
+	}
+
+	testCases := map[string]struct {
+		response *dns.Msg
+		ttl      uint32
+		cache    bool
+	}{
+		"positive answer": {
+			response: &dns.Msg{
+				Answer: []dns.RR{
+					&dns.A{Hdr: dns.RR_Header{Ttl: 300}},
+					&dns.A{Hdr: dns.RR_Header{Ttl: 100}},
+				},
+			},
+			ttl:   100,
+			cache: true,
+		},
+		"NXDOMAIN with SOA": {
+			response: &dns.Msg{
+				MsgHdr: dns.MsgHdr{Rcode: dns.RcodeNameError},
+				Ns:     []dns.RR{soa},
+			},
+			ttl:   600,
+			cache: true,
+		},
+		"NXDOMAIN without SOA": {
+			response: &dns.Msg{
+				MsgHdr: dns.MsgHdr{Rcode: dns.RcodeNameError},
+			},
+		},
+		"NODATA with SOA": {
+			response: &dns.Msg{
+				Ns: []dns.RR{&dns.SOA{
+					Hdr:    dns.RR_Header{Rrtype: dns.TypeSOA, Ttl: 100},
+					Minttl: 600,
+				}},
+			},
+			ttl:   100,
+			cache: true,
+		},
+		"NXDOMAIN capped": {
+			response: &dns.Msg{
+				MsgHdr: dns.MsgHdr{Rcode: dns.RcodeNameError},
+				Ns: []dns.RR{&dns.SOA{
+					Hdr:    dns.RR_Header{Rrtype: dns.TypeSOA, Ttl: 86400},
+					Minttl: 86400,
+				}},
+			},
+			ttl:   3600,
+			cache: true,
+		},
+		"NXDOMAIN with CNAME": {
+			response: &dns.Msg{
+				MsgHdr: dns.MsgHdr{Rcode: dns.RcodeNameError},
+				Answer: []dns.RR{&dns.CNAME{Hdr: dns.RR_Header{Ttl: 60}}},
+				Ns:     []dns.RR{soa},
+			},
+			ttl:   60,
+			cache: true,
+		},
+		"SERVFAIL": {
+			response: &dns.Msg{
+				MsgHdr: dns.MsgHdr{Rcode: dns.RcodeServerFailure},
+			},
+			ttl:   30,
+			cache: true,
+		},
+		"REFUSED": {
+			response: &dns.Msg{
+				MsgHdr: dns.MsgHdr{Rcode: dns.RcodeRefused},
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			const negativeMaxTTL, servFailTTL = 3600, 30
+			ttl, cache := getTTL(testCase.response, negativeMaxTTL, servFailTTL)
+
+			assert.Equal(t, testCase.ttl, ttl)
+			assert.Equal(t, testCase.cache, cache)
+		})
+	}
+}
+
+func Test_clampAnswerTTLs(t *testing.T) {
+	t.Parallel()
+
+	response := &dns.Msg{
+		Answer: []dns.RR{
+			&dns.A{Hdr: dns.RR_Header{Ttl: 10}},
+			&dns.A{Hdr: dns.RR_Header{Ttl: 100}},
+			&dns.A{Hdr: dns.RR_Header{Ttl: 1000}},
+		},
+	}
+
+	clampAnswerTTLs(response, 60, 600)
+
+	assert.Equal(t, uint32(60), response.Answer[0].Header().Ttl)
+	assert.Equal(t, uint32(100), response.Answer[1].Header().Ttl)
+	assert.Equal(t, uint32(600), response.Answer[2].Header().Ttl)
+}
+
+func Test_decrementTTLs(t *testing.T) {
+	t.Parallel()
+
+	opt := &dns.OPT{Hdr: dns.RR_Header{Rrtype: dns.TypeOPT, Ttl: 32768}}
+	response := &dns.Msg{
+		Answer: []dns.RR{&dns.A{Hdr: dns.RR_Header{Ttl: 100}}},
+		Ns:     []dns.RR{&dns.NS{Hdr: dns.RR_Header{Ttl: 5}}},
+		Extra:  []dns.RR{opt},
+	}
+
+	decrementTTLs(response, 10)
+
+	assert.Equal(t, uint32(90), response.Answer[0].Header().Ttl)
+	assert.Equal(t, uint32(0), response.Ns[0].Header().Ttl)
+	assert.Equal(t, uint32(32768), response.Extra[0].Header().Ttl)
+}
+
+func Test_makeKey(t *testing.T) {
+	t.Parallel()
+
+	newRequest := func(name string) *dns.Msg {
+		return new(dns.Msg).SetQuestion(name, dns.TypeA)
+	}
+
+	dnssecOK := newRequest("github.com.").SetEdns0(4096, true)
+	checkingDisabled := newRequest("github.com.")
+	checkingDisabled.CheckingDisabled = true
+	withSubnet := func(ip net.IP, netmask uint8) *dns.Msg {
+		request := newRequest("github.com.").SetEdns0(4096, false)
+		opt := request.IsEdns0()
+		opt.Option = append(opt.Option, &dns.EDNS0_SUBNET{
+			Code:          dns.EDNS0SUBNET,
+			Family:        1,
+			SourceNetmask: netmask,
+			Address:       ip,
+		})
+		return request
+	}
+
+	testCases := map[string]struct {
+		request *dns.Msg
+		key     string
+	}{
+		"plain": {
+			request: newRequest("github.com."),
+			key:     "github.com.|1|1|",
+		},
+		"lowercased": {
+			request: newRequest("GitHub.COM."),
+			key:     "github.com.|1|1|",
+		},
+		"EDNS0 without DO": {
+			request: newRequest("github.com.").SetEdns0(4096, false),
+			key:     "github.com.|1|1|",
+		},
+		"DNSSEC OK": {
+			request: dnssecOK,
+			key:     "github.com.|1|1|do",
+		},
+		"checking disabled": {
+			request: checkingDisabled,
+			key:     "github.com.|1|1|cd",
+		},
+		"client subnet": {
+			request: withSubnet(net.IPv4(1, 2, 3, 4), 24),
+			key:     "github.com.|1|1|",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			key := makeKey(testCase.request)
+
+			assert.Equal(t, testCase.key, key)
+		})
+	}
+}
+
+func newSubnetMsg(ip net.IP, netmask, scope uint8) *dns.Msg {
+	msg := new(dns.Msg).SetQuestion("github.com.", dns.TypeA).SetEdns0(4096, false)
+	opt := msg.IsEdns0()
+	opt.Option = append(opt.Option, &dns.EDNS0_SUBNET{
+		Code:          dns.EDNS0SUBNET,
+		Family:        1,
+		SourceNetmask: netmask,
+		SourceScope:   scope,
+		Address:       ip,
+	})
+	return msg
+}
+
+func Test_responseKey(t *testing.T) {
+	t.Parallel()
+
+	const key = "github.com.|1|1|"
+
+	testCases := map[string]struct {
+		request  *dns.Msg
+		response *dns.Msg
+		key      string
+	}{
+		"no client subnet": {
+			request:  new(dns.Msg).SetQuestion("github.com.", dns.TypeA),
+			response: new(dns.Msg),
+			key:      key,
+		},
+		"response without client subnet": {
+			request:  newSubnetMsg(net.IPv4(1, 2, 3, 4), 24, 0),
+			response: new(dns.Msg),
+			key:      key,
+		},
+		"scope 0": {
+			request:  newSubnetMsg(net.IPv4(1, 2, 3, 4), 24, 0),
+			response: newSubnetMsg(net.IPv4(1, 2, 3, 0), 24, 0),
+			key:      key,
+		},
+		"scope shorter than source": {
+			request:  newSubnetMsg(net.IPv4(1, 2, 3, 4), 24, 0),
+			response: newSubnetMsg(net.IPv4(1, 2, 3, 0), 24, 16),
+			key:      key + "|1.2.0.0/16",
+		},
+		"scope longer than source": {
+			request:  newSubnetMsg(net.IPv4(1, 2, 3, 4), 24, 0),
+			response: newSubnetMsg(net.IPv4(1, 2, 3, 0), 24, 32),
+			key:      key + "|1.2.3.0/24",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			responseKey := responseKey(key, testCase.request, testCase.response)
+
+			assert.Equal(t, testCase.key, responseKey)
+			assert.Equal(t, key, requestKey(responseKey))
+		})
+	}
+}
+
+func Test_lookupKeys(t *testing.T) {
+	t.Parallel()
+
+	const key = "github.com.|1|1|"
+
+	keys := lookupKeys(key, new(dns.Msg).SetQuestion("github.com.", dns.TypeA))
+	assert.Equal(t, []string{key}, keys)
+
+	keys = lookupKeys(key, newSubnetMsg(net.IPv4(1, 2, 3, 4), 3, 0))
+	expectedKeys := []string{
+		key + "|0.0.0.0/3",
+		key + "|0.0.0.0/2",
+		key + "|0.0.0.0/1",
+		key,
+	}
+	assert.Equal(t, expectedKeys, keys)
+
+	keys = lookupKeys(key, newSubnetMsg(net.IPv4(1, 2, 3, 4), 24, 0))
+	assert.Len(t, keys, 25)
+	assert.Equal(t, key+"|1.2.3.0/24", keys[0])
+	assert.Equal(t, key+"|1.2.0.0/16", keys[8])
+}
@@ -2,19 +2,30 @@ package lru
 
 import (
 	"container/list"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/metrics"
 )
 
 type LRU struct {
 	// Configuration
-	maxEntries int
+	maxEntries     int
+	maxBytes       int
+	minTTL         uint32
+	maxTTL         uint32
+	negativeMaxTTL uint32
+	servFailTTL    uint32
+	staleMaxAge    int64 // 0 if serve stale is disabled
+	prefetch       bool
+	metrics        metrics.CacheInterface
 
 	// State
 	kv         map[string]*list.Element
 	linkedList *list.List
+	bytes      int // total size of the cached responses
 	mutex      sync.Mutex
 
 	// Mock fields
@@ -23,11 +34,25 @@ type LRU struct {
 
 func New(settings Settings) *LRU {
 	settings.SetDefaults()
+
+	var staleMaxAge int64
+	if settings.ServeStale {
+		staleMaxAge = int64(settings.StaleMaxAge.Seconds())
+	}
+
 	return &LRU{
-		maxEntries: settings.MaxEntries,
-		kv:         make(map[string]*list.Element, settings.MaxEntries),
-		linkedList: list.New(),
-		timeNow:    time.Now,
+		maxEntries:     settings.MaxEntries,
+		maxBytes:       settings.MaxBytes,
+		minTTL:         uint32(settings.MinTTL.Seconds()),
+		maxTTL:         uint32(settings.MaxTTL.Seconds()),
+		negativeMaxTTL: uint32(settings.NegativeMaxTTL.Seconds()),
+		servFailTTL:    uint32(settings.ServFailTTL.Seconds()),
+		staleMaxAge:    staleMaxAge,
+		prefetch:       settings.Prefetch,
+		metrics:        settings.Metrics,
+		kv:             make(map[string]*list.Element, settings.MaxEntries),
+		linkedList:     list.New(),
+		timeNow:        time.Now,
 	}
 }
 
@@ -36,51 +61,117 @@ func (l *LRU) Add(request, response *dns.Msg) {
 		// cannot make key if there is no question
 		return
 	}
+	l.add(responseKey(makeKey(request), request, response), response)
+}
 
-	key := makeKey(request)
-	expUnix := getExpUnix(response, l.timeNow().Unix())
+func (l *LRU) add(key string, response *dns.Msg) {
 	responseCopy := response.Copy()
+	clampAnswerTTLs(responseCopy, l.minTTL, l.maxTTL)
+
+	ttl, cache := getTTL(responseCopy, l.negativeMaxTTL, l.servFailTTL)
+	if !cache {
+		return
+	}
+	capSOATTL(responseCopy, ttl)
+
+	size := responseCopy.Len()
+	if l.maxBytes > 0 && size > l.maxBytes {
+		// response is too large to ever fit in the cache
+		return
+	}
+
+	nowUnix := l.timeNow().Unix()
 
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
 
-	if listElement, ok := l.kv[key]; ok {
+	l.insert(&entry{
+		key:       key,
+		addedUnix: nowUnix,
+		expUnix:   nowUnix + int64(ttl),
+		response:  responseCopy,
+		size:      size,
+	})
+}
+
+// insert inserts the entry as the most recently used entry,
+// replacing any existing entry with the same key, and evicts
+// least recently used entries if needed.
+// It is NOT thread safe and its parent should have
+// a locking mechanism to stay thread safe.
+func (l *LRU) insert(newEntry *entry) {
+	if listElement, ok := l.kv[newEntry.key]; ok {
 		l.linkedList.MoveToFront(listElement)
 		entryPtr := listElement.Value.(*entry)
-		entryPtr.expUnix = expUnix
-		entryPtr.response = responseCopy
+		l.addBytes(newEntry.size - entryPtr.size)
+		*entryPtr = *newEntry
+		l.evict()
 		return
 	}
 
-	entry := &entry{
-		key:      key,
-		expUnix:  expUnix,
-		response: responseCopy,
-	}
-
-	listElement := l.linkedList.PushFront(entry)
-	l.kv[key] = listElement
+	listElement := l.linkedList.PushFront(newEntry)
+	l.kv[newEntry.key] = listElement
+	l.addBytes(newEntry.size)
+	l.evict()
+}
 
-	if l.maxEntries > 0 && l.linkedList.Len() > l.maxEntries {
+// evict removes the least recently used entries until the cache
+// is within its maximum number of entries and maximum size.
+// It never removes the most recently used entry.
+// It is NOT thread safe and its parent should have
+// a locking mechanism to stay thread safe.
+func (l *LRU) evict() {
+	for l.linkedList.Len() > 1 &&
+		((l.maxEntries > 0 && l.linkedList.Len() > l.maxEntries) ||
+			(l.maxBytes > 0 && l.bytes > l.maxBytes)) {
 		l.removeOldest()
+		l.metrics.CacheEviction()
 	}
 }
 
-func (l *LRU) Get(request *dns.Msg) (response *dns.Msg) {
+// addBytes adds delta to the total size of the cached responses.
+// It is NOT thread safe and its parent should have
+// a locking mechanism to stay thread safe.
+func (l *LRU) addBytes(delta int) {
+	l.bytes += delta
+	l.metrics.CacheBytes(delta)
+}
+
+// Get returns a copy of the cached response for the request with its
+// TTLs decremented, or nil if there is none. The prefetch boolean
+// is true if prefetching is enabled and the response is in the last
+// 10% of its TTL, in which case the caller should refresh it.
+// It is only true once for each response cached.
+func (l *LRU) Get(request *dns.Msg) (response *dns.Msg, prefetch bool) {
 	if len(request.Question) == 0 {
 		// cannot make key if there is no question
-		return
+		return nil, false
 	}
+	return l.get(lookupKeys(makeKey(request), request))
+}
 
-	key := makeKey(request)
+// lookup returns the list element of the first key found in the
+// keys given. It must be called with the mutex locked.
+func (l *LRU) lookup(keys []string) (listElement *list.Element, ok bool) {
+	for _, key := range keys {
+		listElement, ok = l.kv[key]
+		if ok {
+			return listElement, true
+		}
+	}
+	return nil, false
+}
+
+func (l *LRU) get(keys []string) (response *dns.Msg, prefetch bool) {
 	nowUnix := l.timeNow().Unix()
 
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
 
-	listElement, ok := l.kv[key]
+	listElement, ok := l.lookup(keys)
 	if !ok {
-		return nil
+		l.metrics.CacheMiss()
+		return nil, false
 	}
 
 	l.linkedList.MoveToFront(listElement)
@@ -88,11 +179,125 @@ func (l *LRU) Get(request *dns.Msg) (response *dns.Msg) {
 
 	if nowUnix >= entryPtr.expUnix {
 		// expired record
+		if nowUnix >= entryPtr.expUnix+l.staleMaxAge {
+			l.remove(listElement)
+		} // else keep it to eventually serve it stale
+		l.metrics.CacheMiss()
+		return nil, false
+	}
+
+	l.metrics.CacheHit()
+
+	const prefetchRatio = 10 // last 10% of the TTL
+	ttl := entryPtr.expUnix - entryPtr.addedUnix
+	remaining := entryPtr.expUnix - nowUnix
+	if l.prefetch && !entryPtr.prefetching && remaining*prefetchRatio <= ttl {
+		entryPtr.prefetching = true
+		prefetch = true
+	}
+
+	response = entryPtr.response.Copy()
+	decrementTTLs(response, uint32(nowUnix-entryPtr.addedUnix))
+	return response, prefetch
+}
+
+// GetStale returns a copy of the expired cached response for the
+// request if serve stale is enabled and the response expired less
+// than the stale max age ago, or nil otherwise. All its TTLs are
+// set to 30 seconds as recommended by RFC 8767. It should only be
+// used when the upstream exchange fails.
+func (l *LRU) GetStale(request *dns.Msg) (response *dns.Msg) {
+	if len(request.Question) == 0 {
+		// cannot make key if there is no question
+		return nil
+	}
+	return l.getStale(lookupKeys(makeKey(request), request))
+}
+
+func (l *LRU) getStale(keys []string) (response *dns.Msg) {
+	if l.staleMaxAge == 0 {
+		return nil
+	}
+
+	nowUnix := l.timeNow().Unix()
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	listElement, ok := l.lookup(keys)
+	if !ok {
+		return nil
+	}
+
+	entryPtr := listElement.Value.(*entry)
+	if nowUnix >= entryPtr.expUnix+l.staleMaxAge {
 		l.remove(listElement)
 		return nil
 	}
 
-	return entryPtr.response.Copy()
+	l.linkedList.MoveToFront(listElement)
+	response = entryPtr.response.Copy()
+	if nowUnix < entryPtr.expUnix {
+		// the entry was refreshed in the meantime
+		decrementTTLs(response, uint32(nowUnix-entryPtr.addedUnix))
+		return response
+	}
+
+	const staleTTL = 30 // RFC 8767 section 4
+	setTTLs(response, staleTTL)
+	return response
+}
+
+// Remove removes all the cached responses for the name given and,
+// if subdomains is true, for all its subdomains. It returns the
+// number of cached responses removed.
+func (l *LRU) Remove(name string, subdomains bool) (removed int) {
+	name = strings.ToLower(dns.Fqdn(name))
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	var next *list.Element
+	for listElement := l.linkedList.Front(); listElement != nil; listElement = next {
+		next = listElement.Next()
+		if listElement.Value.(*entry).matches(name, subdomains) {
+			l.remove(listElement)
+			removed++
+		}
+	}
+	return removed
+}
+
+// Flush removes all the cached responses.
+func (l *LRU) Flush() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for listElement := l.linkedList.Front(); listElement != nil; listElement = l.linkedList.Front() {
+		l.remove(listElement)
+	}
+}
+
+// Len returns the number of cached responses,
+// including expired ones not yet removed.
+func (l *LRU) Len() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.linkedList.Len()
+}
+
+// Range calls f for each cached response from the most recently
+// used to the least recently used, until f returns false.
+// f must not call any method of the cache.
+func (l *LRU) Range(f func(entry Entry) bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for listElement := l.linkedList.Front(); listElement != nil; listElement = listElement.Next() {
+		if !f(listElement.Value.(*entry).export()) {
+			return
+		}
+	}
 }
 
 // remove removes a list element
@@ -102,6 +307,7 @@ func (l *LRU) remove(listElement *list.Element) {
 	l.linkedList.Remove(listElement)
 	entryPtr := listElement.Value.(*entry)
 	delete(l.kv, entryPtr.key)
+	l.addBytes(-entryPtr.size)
 }
 
 // It is NOT thread safe and its parent should have
@@ -1,18 +1,24 @@
 package lru
 
 import (
+	"net"
 	"testing"
 	"time"
 
+	"github.com/golang/mock/gomock"
 	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/metrics/mock_metrics"
 	"github.com/stretchr/testify/assert"
 )
 
-func newTestMsgs(name string, expUnix uint32) (request, response *dns.Msg) {
+func newTestMsgs(name string, ttl uint32) (request, response *dns.Msg) {
 	request = &dns.Msg{Question: []dns.Question{{Name: name}}}
 	response = &dns.Msg{Answer: []dns.RR{&dns.TXT{
 		Txt: []string{name},
-		Hdr: dns.RR_Header{Ttl: expUnix},
+		Hdr: dns.RR_Header{
+			Name: name, Rrtype: dns.TypeTXT,
+			Class: dns.ClassINET, Ttl: ttl,
+		},
 	}}}
 	response = response.Copy() // transform nil slices -> empty slices
 	return request, response
@@ -21,19 +27,25 @@ func newTestMsgs(name string, expUnix uint32) (request, response *dns.Msg) {
 func Test_lru_e2e(t *testing.T) {
 	t.Parallel()
 
-	nowUnix := uint32(time.Now().Unix())
-	expUnix := nowUnix + 1000
-
 	const (
 		maxEntries = 2
+		ttl        = 1000
 	)
+	mockCtrl := gomock.NewController(t)
+	metrics := mock_metrics.NewMockInterface(mockCtrl)
+	metrics.EXPECT().CacheBytes(gomock.Any()).AnyTimes()
+	metrics.EXPECT().CacheEviction()
+	metrics.EXPECT().CacheHit().Times(2)
+	metrics.EXPECT().CacheMiss()
+
 	settings := Settings{
 		MaxEntries: maxEntries,
+		Metrics:    metrics,
 	}
 
-	requestA, responseA := newTestMsgs("A", expUnix)
-	requestB, responseB := newTestMsgs("B", expUnix)
-	requestC, responseC := newTestMsgs("C", expUnix)
+	requestA, responseA := newTestMsgs("A", ttl)
+	requestB, responseB := newTestMsgs("B", ttl)
+	requestC, responseC := newTestMsgs("C", ttl)
 
 	lru := New(settings)
 
@@ -42,12 +54,205 @@ func Test_lru_e2e(t *testing.T) {
 	lru.Add(requestA, responseA)
 	lru.Add(requestC, responseC)
 
-	response := lru.Get(requestA)
+	response, _ := lru.Get(requestA)
 	assert.Equal(t, responseA, response)
 
-	response = lru.Get(requestB)
+	response, _ = lru.Get(requestB)
 	assert.Nil(t, response)
 
-	response = lru.Get(requestC)
+	response, _ = lru.Get(requestC)
 	assert.Equal(t, responseC, response)
 }
+
+func Test_lru_ttl(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	metrics := mock_metrics.NewMockInterface(mockCtrl)
+	metrics.EXPECT().CacheBytes(gomock.Any()).AnyTimes()
+	metrics.EXPECT().CacheHit()
+	metrics.EXPECT().CacheMiss()
+
+	settings := Settings{
+		MinTTL:  time.Minute,
+		MaxTTL:  time.Hour,
+		Metrics: metrics,
+	}
+
+	lru := New(settings)
+	now := time.Unix(1000, 0)
+	lru.timeNow = func() time.Time { return now }
+
+	request, response := newTestMsgs("A", 10)
+	lru.Add(request, response)
+
+	now = now.Add(20 * time.Second)
+	cached, _ := lru.Get(request)
+	assert.Equal(t, uint32(40), cached.Answer[0].Header().Ttl)
+	assert.Equal(t, uint32(10), response.Answer[0].Header().Ttl)
+
+	now = now.Add(40 * time.Second)
+	cached, _ = lru.Get(request)
+	assert.Nil(t, cached)
+}
+
+func Test_lru_serveStale(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	metrics := mock_metrics.NewMockInterface(mockCtrl)
+	metrics.EXPECT().CacheBytes(gomock.Any()).AnyTimes()
+	metrics.EXPECT().CacheMiss().Times(2)
+
+	settings := Settings{
+		ServeStale:  true,
+		StaleMaxAge: time.Hour,
+		Metrics:     metrics,
+	}
+
+	lru := New(settings)
+	now := time.Unix(1000, 0)
+	lru.timeNow = func() time.Time { return now }
+
+	request, response := newTestMsgs("A", 10)
+	lru.Add(request, response)
+
+	now = now.Add(time.Minute)
+	cached, _ := lru.Get(request)
+	assert.Nil(t, cached)
+
+	stale := lru.GetStale(request)
+	if assert.NotNil(t, stale) {
+		assert.Equal(t, uint32(30), stale.Answer[0].Header().Ttl)
+	}
+
+	now = now.Add(time.Hour)
+	cached, _ = lru.Get(request)
+	assert.Nil(t, cached)
+	stale = lru.GetStale(request)
+	assert.Nil(t, stale)
+}
+
+func Test_lru_prefetch(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	metrics := mock_metrics.NewMockInterface(mockCtrl)
+	metrics.EXPECT().CacheBytes(gomock.Any()).AnyTimes()
+	metrics.EXPECT().CacheHit().Times(4)
+
+	settings := Settings{
+		Prefetch: true,
+		Metrics:  metrics,
+	}
+
+	lru := New(settings)
+	now := time.Unix(1000, 0)
+	lru.timeNow = func() time.Time { return now }
+
+	request, response := newTestMsgs("A", 100)
+	lru.Add(request, response)
+
+	_, prefetch := lru.Get(request)
+	assert.False(t, prefetch)
+
+	now = now.Add(95 * time.Second)
+	_, prefetch = lru.Get(request)
+	assert.True(t, prefetch)
+	_, prefetch = lru.Get(request)
+	assert.False(t, prefetch) // only signaled once
+
+	lru.Add(request, response)
+	_, prefetch = lru.Get(request)
+	assert.False(t, prefetch)
+}
+
+func Test_lru_maxBytes(t *testing.T) {
+	t.Parallel()
+
+	requestA, responseA := newTestMsgs("A", 1000)
+	requestB, responseB := newTestMsgs("B", 1000)
+	requestC, responseC := newTestMsgs("C", 1000)
+	size := responseA.Len()
+
+	mockCtrl := gomock.NewController(t)
+	metrics := mock_metrics.NewMockInterface(mockCtrl)
+	metrics.EXPECT().CacheBytes(size).Times(3)
+	metrics.EXPECT().CacheBytes(-size)
+	metrics.EXPECT().CacheEviction()
+
+	settings := Settings{
+		MaxBytes: 2*size + 1,
+		Metrics:  metrics,
+	}
+	lru := New(settings)
+
+	lru.Add(requestA, responseA)
+	lru.Add(requestB, responseB)
+	assert.Equal(t, 2*size, lru.bytes)
+
+	lru.Add(requestC, responseC)
+	assert.Equal(t, 2*size, lru.bytes)
+	_, ok := lru.kv[makeKey(requestA)]
+	assert.False(t, ok)
+
+	// Response larger than the budget is not cached
+	requestD, responseD := newTestMsgs("D", 1000)
+	responseD.Answer[0].(*dns.TXT).Txt = []string{string(make([]byte, 3*size))}
+	lru.Add(requestD, responseD)
+	assert.Equal(t, 2*size, lru.bytes)
+}
+
+func Test_lru_administration(t *testing.T) {
+	t.Parallel()
+
+	lru := New(Settings{})
+	for _, name := range []string{"github.com.", "api.github.com.", "notgithub.com.", "google.com."} {
+		request, response := newTestMsgs(name, 1000)
+		response.Question = request.Question
+		lru.Add(request, response)
+	}
+	assert.Equal(t, 4, lru.Len())
+
+	var names []string
+	lru.Range(func(entry Entry) bool {
+		names = append(names, entry.Name)
+		return len(names) < 2
+	})
+	assert.Equal(t, []string{"google.com.", "notgithub.com."}, names)
+
+	removed := lru.Remove("GitHub.com", true)
+	assert.Equal(t, 2, removed)
+	assert.Equal(t, 2, lru.Len())
+
+	lru.Flush()
+	assert.Equal(t, 0, lru.Len())
+	assert.Equal(t, 0, lru.bytes)
+}
+
+func Test_lru_clientSubnetScope(t *testing.T) {
+	t.Parallel()
+
+	lru := New(Settings{})
+
+	request := newSubnetMsg(net.IPv4(1, 2, 3, 4), 24, 0)
+	response := newSubnetMsg(net.IPv4(1, 2, 3, 0), 24, 16)
+	response.Response = true
+	response.Answer = []dns.RR{&dns.A{
+		Hdr: dns.RR_Header{Name: "github.com.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+		A:   net.IP{5, 6, 7, 8},
+	}}
+	lru.Add(request, response)
+
+	// same /16 scope
+	cached, _ := lru.Get(newSubnetMsg(net.IPv4(1, 2, 200, 1), 24, 0))
+	assert.NotNil(t, cached)
+
+	// different /16 scope
+	cached, _ = lru.Get(newSubnetMsg(net.IPv4(1, 3, 3, 4), 24, 0))
+	assert.Nil(t, cached)
+
+	// no client subnet
+	cached, _ = lru.Get(new(dns.Msg).SetQuestion("github.com.", dns.TypeA))
+	assert.Nil(t, cached)
+}
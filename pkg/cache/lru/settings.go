@@ -3,16 +3,83 @@ package lru
 import (
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/qdm12/dns/pkg/metrics"
 )
 
 type Settings struct {
 	MaxEntries int
+	// MaxBytes is the maximum total size in bytes of the cached
+	// responses in wire format. Least recently used entries are
+	// evicted until the cache is under this budget. It defaults
+	// to 0 meaning the cache size is only bounded by MaxEntries.
+	MaxBytes int
+	// MinTTL is the minimum TTL duration to cache positive
+	// responses for, and defaults to 0 for no minimum.
+	// Answer records TTLs are raised to it if lower.
+	MinTTL time.Duration
+	// MaxTTL is the maximum TTL duration to cache positive
+	// responses for, and defaults to 24 hours.
+	// Answer records TTLs are lowered to it if higher,
+	// and it takes precedence over MinTTL.
+	MaxTTL time.Duration
+	// NegativeMaxTTL is the maximum duration to cache negative
+	// responses for, such as NXDOMAIN responses, and defaults to 3 hours.
+	NegativeMaxTTL time.Duration
+	// ServFailTTL is the duration to cache SERVFAIL responses for,
+	// and defaults to 30 seconds if nil. It cannot be longer than
+	// 5 minutes, and SERVFAIL responses are not cached if it is 0.
+	ServFailTTL *time.Duration
+	// ServeStale enables serving expired responses following
+	// RFC 8767 if the upstream exchange fails, for at most
+	// StaleMaxAge after their expiry.
+	ServeStale bool
+	// StaleMaxAge is the duration to keep expired responses
+	// for when ServeStale is enabled, and defaults to 24 hours.
+	StaleMaxAge time.Duration
+	// Prefetch enables signaling responses requested in the last
+	// 10% of their TTL should be refreshed, as Unbound does.
+	Prefetch bool
+	// Metrics is the interface to record cache metrics,
+	// and defaults to a no-op implementation.
+	Metrics metrics.CacheInterface
 }
 
 func (s *Settings) SetDefaults() {
 	if s.MaxEntries == 0 {
 		s.MaxEntries = 10e4
 	}
+
+	if s.MaxTTL == 0 {
+		const defaultMaxTTL = 24 * time.Hour
+		s.MaxTTL = defaultMaxTTL
+	}
+
+	if s.NegativeMaxTTL == 0 {
+		const defaultNegativeMaxTTL = 3 * time.Hour
+		s.NegativeMaxTTL = defaultNegativeMaxTTL
+	}
+
+	// RFC 2308 section 7.1
+	const maxServFailTTL = 5 * time.Minute
+	if s.ServFailTTL == nil {
+		const defaultServFailTTL = 30 * time.Second
+		servFailTTL := defaultServFailTTL
+		s.ServFailTTL = &servFailTTL
+	} else if *s.ServFailTTL > maxServFailTTL {
+		servFailTTL := maxServFailTTL
+		s.ServFailTTL = &servFailTTL
+	}
+
+	if s.StaleMaxAge == 0 {
+		const defaultStaleMaxAge = 24 * time.Hour
+		s.StaleMaxAge = defaultStaleMaxAge
+	}
+
+	if s.Metrics == nil {
+		s.Metrics = metrics.NewNoop()
+	}
 }
 
 func (s *Settings) String() string {
@@ -25,5 +92,34 @@ func (s *Settings) String() string {
 
 func (s *Settings) Lines(indent, subSection string) (lines []string) {
 	lines = append(lines, subSection+"Max entries: "+strconv.Itoa(s.MaxEntries))
+	maxBytes := "unlimited"
+	if s.MaxBytes > 0 {
+		maxBytes = strconv.Itoa(s.MaxBytes) + " bytes"
+	}
+	lines = append(lines, subSection+"Max size: "+maxBytes)
+	minTTL := "none"
+	if s.MinTTL > 0 {
+		minTTL = s.MinTTL.String()
+	}
+	lines = append(lines, subSection+"Min TTL: "+minTTL)
+	lines = append(lines, subSection+"Max TTL: "+s.MaxTTL.String())
+	lines = append(lines, subSection+"Negative responses max TTL: "+s.NegativeMaxTTL.String())
+	servFailTTL := "disabled"
+	if *s.ServFailTTL > 0 {
+		servFailTTL = s.ServFailTTL.String()
+	}
+	lines = append(lines, subSection+"SERVFAIL responses TTL: "+servFailTTL)
+
+	serveStale := "disabled"
+	if s.ServeStale {
+		serveStale = "up to " + s.StaleMaxAge.String() + " after expiry"
+	}
+	lines = append(lines, subSection+"Serve stale: "+serveStale)
+
+	prefetch := "disabled"
+	if s.Prefetch {
+		prefetch = "enabled"
+	}
+	lines = append(lines, subSection+"Prefetch: "+prefetch)
 	return lines
 }
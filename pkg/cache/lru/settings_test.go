@@ -2,6 +2,7 @@ package lru
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -12,3 +13,23 @@ func Test_Settings_SetDefaults(t *testing.T) {
 
 	assert.Greater(t, settings.MaxEntries, 1)
 }
+
+func Test_Settings_SetDefaults_servFailTTL(t *testing.T) {
+	servFailTTL := time.Hour
+	settings := Settings{ServFailTTL: &servFailTTL}
+	settings.SetDefaults()
+
+	assert.Equal(t, 5*time.Minute, *settings.ServFailTTL)
+	assert.Equal(t, time.Hour, servFailTTL)
+	assert.Equal(t, 3*time.Hour, settings.NegativeMaxTTL)
+
+	settings = Settings{}
+	settings.SetDefaults()
+	assert.Equal(t, 30*time.Second, *settings.ServFailTTL)
+
+	servFailTTL = 0
+	settings = Settings{ServFailTTL: &servFailTTL}
+	settings.SetDefaults()
+	assert.Equal(t, time.Duration(0), *settings.ServFailTTL)
+	assert.Contains(t, settings.Lines("", ""), "SERVFAIL responses TTL: disabled")
+}
@@ -0,0 +1,162 @@
+package lru
+
+import (
+	"bufio"
+	"errors"
+	"io"
+
+	"github.com/miekg/dns"
+)
+
+// Sharded is a cache partitioning its entries across multiple
+// LRU caches, each with their own lock, to reduce lock contention
+// when the cache is used concurrently.
+type Sharded struct {
+	shards []*LRU
+}
+
+// NewSharded creates a sharded LRU cache with the number of shards
+// given, where the settings max entries and max bytes are split
+// evenly between the shards. The number of shards defaults to 16 if 0.
+func NewSharded(settings Settings, shards int) *Sharded {
+	settings.SetDefaults()
+	if shards == 0 {
+		const defaultShards = 16
+		shards = defaultShards
+	}
+
+	// Round up so the total number of entries is at least MaxEntries.
+	settings.MaxEntries = (settings.MaxEntries + shards - 1) / shards
+	settings.MaxBytes = (settings.MaxBytes + shards - 1) / shards
+
+	s := &Sharded{
+		shards: make([]*LRU, shards),
+	}
+	for i := range s.shards {
+		s.shards[i] = New(settings)
+	}
+	return s
+}
+
+func (s *Sharded) Add(request, response *dns.Msg) {
+	if len(request.Question) == 0 {
+		// cannot make key if there is no question
+		return
+	}
+	// The shard is picked using the request key so responses
+	// for all client subnets are in the same shard.
+	key := makeKey(request)
+	s.shardFor(key).add(responseKey(key, request, response), response)
+}
+
+func (s *Sharded) Get(request *dns.Msg) (response *dns.Msg, prefetch bool) {
+	if len(request.Question) == 0 {
+		// cannot make key if there is no question
+		return nil, false
+	}
+	key := makeKey(request)
+	return s.shardFor(key).get(lookupKeys(key, request))
+}
+
+func (s *Sharded) GetStale(request *dns.Msg) (response *dns.Msg) {
+	if len(request.Question) == 0 {
+		// cannot make key if there is no question
+		return nil
+	}
+	key := makeKey(request)
+	return s.shardFor(key).getStale(lookupKeys(key, request))
+}
+
+// shardFor returns the shard for the cache key given,
+// using the FNV-1a hash of the key.
+func (s *Sharded) shardFor(key string) *LRU {
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+	hash := uint32(offset32)
+	for i := 0; i < len(key); i++ {
+		hash ^= uint32(key[i])
+		hash *= prime32
+	}
+	return s.shards[hash%uint32(len(s.shards))]
+}
+
+// Save writes a snapshot of the entries of all the shards to the writer.
+func (s *Sharded) Save(w io.Writer) (err error) {
+	bufferedWriter := bufio.NewWriter(w)
+
+	if err := writeSnapshotHeader(bufferedWriter); err != nil {
+		return err
+	}
+
+	for _, shard := range s.shards {
+		if err := shard.writeEntries(bufferedWriter); err != nil {
+			return err
+		}
+	}
+
+	return bufferedWriter.Flush()
+}
+
+// Load loads the cache entries from a snapshot read from the reader,
+// in their respective shards. The snapshot can come from a cache
+// with a different number of shards or from a non sharded cache.
+func (s *Sharded) Load(r io.Reader) (err error) {
+	bufferedReader := bufio.NewReader(r)
+
+	if err := readSnapshotHeader(bufferedReader); err != nil {
+		return err
+	}
+
+	for {
+		entry, err := readEntry(bufferedReader)
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		shard := s.shardFor(requestKey(entry.key))
+		shard.load(entry, shard.timeNow().Unix())
+	}
+}
+
+// Remove removes all the cached responses for the name given and,
+// if subdomains is true, for all its subdomains, from all the shards.
+// It returns the number of cached responses removed.
+func (s *Sharded) Remove(name string, subdomains bool) (removed int) {
+	for _, shard := range s.shards {
+		removed += shard.Remove(name, subdomains)
+	}
+	return removed
+}
+
+// Flush removes all the cached responses from all the shards.
+func (s *Sharded) Flush() {
+	for _, shard := range s.shards {
+		shard.Flush()
+	}
+}
+
+// Len returns the number of cached responses in all the shards.
+func (s *Sharded) Len() (length int) {
+	for _, shard := range s.shards {
+		length += shard.Len()
+	}
+	return length
+}
+
+// Range calls f for each cached response of each shard, until f
+// returns false. f must not call any method of the cache.
+func (s *Sharded) Range(f func(entry Entry) bool) {
+	stopped := false
+	for _, shard := range s.shards {
+		shard.Range(func(entry Entry) bool {
+			stopped = !f(entry)
+			return !stopped
+		})
+		if stopped {
+			return
+		}
+	}
+}
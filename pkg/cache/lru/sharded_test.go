@@ -0,0 +1,74 @@
+package lru
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewSharded(t *testing.T) {
+	t.Parallel()
+
+	sharded := NewSharded(Settings{MaxEntries: 10}, 4)
+
+	assert.Len(t, sharded.shards, 4)
+	for _, shard := range sharded.shards {
+		assert.Equal(t, 3, shard.maxEntries)
+	}
+}
+
+func Test_Sharded_e2e(t *testing.T) {
+	t.Parallel()
+
+	sharded := NewSharded(Settings{}, 0)
+
+	request, response := newTestMsgs("github.com.", 1000)
+	sharded.Add(request, response)
+
+	cached, _ := sharded.Get(request)
+	assert.Equal(t, response, cached)
+
+	upperRequest, _ := newTestMsgs("GitHub.com.", 1000)
+	cached, _ = sharded.Get(upperRequest)
+	assert.Equal(t, response, cached)
+
+	otherRequest, _ := newTestMsgs("google.com.", 1000)
+	cached, _ = sharded.Get(otherRequest)
+	assert.Nil(t, cached)
+}
+
+func benchmarkParallel(b *testing.B, cache interface {
+	Add(request, response *dns.Msg)
+	Get(request *dns.Msg) (response *dns.Msg, prefetch bool)
+}) {
+	const names = 1000
+	requests := make([]*dns.Msg, names)
+	responses := make([]*dns.Msg, names)
+	for i := range requests {
+		requests[i], responses[i] = newTestMsgs(strconv.Itoa(i)+".com.", 1000)
+		cache.Add(requests[i], responses[i])
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			if i%10 == 0 {
+				cache.Add(requests[i%names], responses[i%names])
+			} else {
+				cache.Get(requests[i%names])
+			}
+			i++
+		}
+	})
+}
+
+func Benchmark_LRU_parallel(b *testing.B) {
+	benchmarkParallel(b, New(Settings{}))
+}
+
+func Benchmark_Sharded_parallel(b *testing.B) {
+	benchmarkParallel(b, NewSharded(Settings{}, 0))
+}
@@ -0,0 +1,210 @@
+package lru
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/miekg/dns"
+)
+
+// The snapshot format is a header made of the snapshot magic and
+// version, followed by the cache entries from the least recently
+// used to the most recently used. Each entry is made of:
+// - the key length as a big endian uint16
+// - the key
+// - the Unix time the response was cached at as a big endian int64
+// - the Unix expiry time of the response as a big endian int64
+// - the response wire format length as a big endian uint16
+// - the response in wire format.
+const (
+	snapshotMagic   = "qdm12/dns cache"
+	snapshotVersion = 2
+)
+
+var (
+	ErrSnapshotMagic   = errors.New("snapshot magic is invalid")
+	ErrSnapshotVersion = errors.New("snapshot version is not supported")
+)
+
+// Save writes a snapshot of the cache entries to the writer.
+func (l *LRU) Save(w io.Writer) (err error) {
+	bufferedWriter := bufio.NewWriter(w)
+
+	if err := writeSnapshotHeader(bufferedWriter); err != nil {
+		return err
+	}
+
+	if err := l.writeEntries(bufferedWriter); err != nil {
+		return err
+	}
+
+	return bufferedWriter.Flush()
+}
+
+// Load loads the cache entries from a snapshot read from the reader.
+// Entries expired, or past their serve stale window if serve stale
+// is enabled, are dropped.
+func (l *LRU) Load(r io.Reader) (err error) {
+	bufferedReader := bufio.NewReader(r)
+
+	if err := readSnapshotHeader(bufferedReader); err != nil {
+		return err
+	}
+
+	nowUnix := l.timeNow().Unix()
+	for {
+		entry, err := readEntry(bufferedReader)
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		l.load(entry, nowUnix)
+	}
+}
+
+// load inserts the entry in the cache if it is not expired.
+func (l *LRU) load(entry *entry, nowUnix int64) {
+	if nowUnix >= entry.expUnix+l.staleMaxAge {
+		return
+	}
+
+	if l.maxBytes > 0 && entry.size > l.maxBytes {
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.insert(entry)
+}
+
+// writeEntries writes the cache entries from the least
+// recently used one to the most recently used one.
+func (l *LRU) writeEntries(w io.Writer) (err error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for listElement := l.linkedList.Back(); listElement != nil; listElement = listElement.Prev() {
+		if err := writeEntry(w, listElement.Value.(*entry)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeSnapshotHeader(w io.Writer) (err error) {
+	if _, err := io.WriteString(w, snapshotMagic); err != nil {
+		return fmt.Errorf("cannot write snapshot magic: %w", err)
+	}
+
+	if err := binary.Write(w, binary.BigEndian, uint8(snapshotVersion)); err != nil {
+		return fmt.Errorf("cannot write snapshot version: %w", err)
+	}
+
+	return nil
+}
+
+func readSnapshotHeader(r io.Reader) (err error) {
+	magic := make([]byte, len(snapshotMagic))
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return fmt.Errorf("cannot read snapshot magic: %w", err)
+	} else if string(magic) != snapshotMagic {
+		return fmt.Errorf("%w: %q", ErrSnapshotMagic, magic)
+	}
+
+	var version uint8
+	if err := binary.Read(r, binary.BigEndian, &version); err != nil {
+		return fmt.Errorf("cannot read snapshot version: %w", err)
+	} else if version != snapshotVersion {
+		return fmt.Errorf("%w: %d", ErrSnapshotVersion, version)
+	}
+
+	return nil
+}
+
+func writeEntry(w io.Writer, entry *entry) (err error) {
+	wire, err := entry.response.Pack()
+	if err != nil {
+		return fmt.Errorf("cannot pack response for %s: %w", entry.key, err)
+	}
+
+	if err := writeBytes(w, []byte(entry.key)); err != nil {
+		return fmt.Errorf("cannot write entry key: %w", err)
+	}
+
+	times := [2]int64{entry.addedUnix, entry.expUnix}
+	if err := binary.Write(w, binary.BigEndian, times); err != nil {
+		return fmt.Errorf("cannot write entry times: %w", err)
+	}
+
+	if err := writeBytes(w, wire); err != nil {
+		return fmt.Errorf("cannot write entry response: %w", err)
+	}
+
+	return nil
+}
+
+// readEntry reads an entry from the reader, and returns
+// io.EOF if there is no more entry to read.
+func readEntry(r io.Reader) (entryPtr *entry, err error) {
+	key, err := readBytes(r)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("cannot read entry key: %w", err)
+	}
+
+	var times [2]int64
+	if err := binary.Read(r, binary.BigEndian, &times); err != nil {
+		return nil, fmt.Errorf("cannot read entry times: %w", err)
+	}
+
+	wire, err := readBytes(r)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read entry response: %w", err)
+	}
+
+	response := new(dns.Msg)
+	if err := response.Unpack(wire); err != nil {
+		return nil, fmt.Errorf("cannot unpack response for %s: %w", key, err)
+	}
+
+	return &entry{
+		key:       string(key),
+		addedUnix: times[0],
+		expUnix:   times[1],
+		response:  response,
+		size:      len(wire),
+	}, nil
+}
+
+// writeBytes writes the length of b as a big endian uint16, followed by b.
+func writeBytes(w io.Writer, b []byte) (err error) {
+	if err := binary.Write(w, binary.BigEndian, uint16(len(b))); err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// readBytes reads bytes written with writeBytes, and returns
+// io.EOF if the reader is at its end before reading the length.
+func readBytes(r io.Reader) (b []byte, err error) {
+	var length uint16
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return nil, err
+	}
+	b = make([]byte, length)
+	if _, err := io.ReadFull(r, b); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return b, nil
+}
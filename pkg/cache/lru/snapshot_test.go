@@ -0,0 +1,83 @@
+package lru
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LRU_snapshot(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1000, 0)
+	timeNow := func() time.Time { return now }
+
+	source := New(Settings{})
+	source.timeNow = timeNow
+
+	requestA, responseA := newTestMsgs("A.", 100)
+	requestB, responseB := newTestMsgs("B.", 10)
+	requestC, responseC := newTestMsgs("C.", 100)
+	source.Add(requestA, responseA)
+	source.Add(requestB, responseB)
+	source.Add(requestC, responseC)
+
+	buffer := bytes.NewBuffer(nil)
+	err := source.Save(buffer)
+	require.NoError(t, err)
+
+	now = now.Add(50 * time.Second)
+	destination := New(Settings{})
+	destination.timeNow = timeNow
+	err = destination.Load(buffer)
+	require.NoError(t, err)
+
+	response, _ := destination.Get(requestA)
+	if assert.NotNil(t, response) {
+		assert.Equal(t, uint32(50), response.Answer[0].Header().Ttl)
+		assert.Equal(t, responseA.Answer[0].(*dns.TXT).Txt, response.Answer[0].(*dns.TXT).Txt)
+	}
+
+	response, _ = destination.Get(requestB)
+	assert.Nil(t, response) // expired
+
+	// recency order is preserved
+	assert.Equal(t, makeKey(requestA), destination.linkedList.Front().Value.(*entry).key)
+	assert.Equal(t, makeKey(requestC), destination.linkedList.Back().Value.(*entry).key)
+}
+
+func Test_LRU_Load_invalid(t *testing.T) {
+	t.Parallel()
+
+	lru := New(Settings{})
+
+	err := lru.Load(bytes.NewBufferString("not a snapshot at all"))
+	assert.ErrorIs(t, err, ErrSnapshotMagic)
+
+	err = lru.Load(bytes.NewBufferString(snapshotMagic + "\x07"))
+	assert.ErrorIs(t, err, ErrSnapshotVersion)
+}
+
+func Test_Sharded_snapshot(t *testing.T) {
+	t.Parallel()
+
+	source := NewSharded(Settings{}, 4)
+	requestA, responseA := newTestMsgs("A.", 100)
+	source.Add(requestA, responseA)
+
+	buffer := bytes.NewBuffer(nil)
+	err := source.Save(buffer)
+	require.NoError(t, err)
+
+	// the snapshot can be loaded in a non sharded cache
+	destination := New(Settings{})
+	err = destination.Load(buffer)
+	require.NoError(t, err)
+
+	response, _ := destination.Get(requestA)
+	assert.NotNil(t, response)
+}
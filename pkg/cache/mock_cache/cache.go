@@ -5,10 +5,12 @@
 package mock_cache
 
 import (
+	io "io"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
 	dns "github.com/miekg/dns"
+	lru "github.com/qdm12/dns/pkg/cache/lru"
 )
 
 // MockCache is a mock of Cache interface.
@@ -46,12 +48,25 @@ func (mr *MockCacheMockRecorder) Add(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockCache)(nil).Add), arg0, arg1)
 }
 
+// Flush mocks base method.
+func (m *MockCache) Flush() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Flush")
+}
+
+// Flush indicates an expected call of Flush.
+func (mr *MockCacheMockRecorder) Flush() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Flush", reflect.TypeOf((*MockCache)(nil).Flush))
+}
+
 // Get mocks base method.
-func (m *MockCache) Get(arg0 *dns.Msg) *dns.Msg {
+func (m *MockCache) Get(arg0 *dns.Msg) (*dns.Msg, bool) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Get", arg0)
 	ret0, _ := ret[0].(*dns.Msg)
-	return ret0
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
 }
 
 // Get indicates an expected call of Get.
@@ -59,3 +74,85 @@ func (mr *MockCacheMockRecorder) Get(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockCache)(nil).Get), arg0)
 }
+
+// GetStale mocks base method.
+func (m *MockCache) GetStale(arg0 *dns.Msg) *dns.Msg {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStale", arg0)
+	ret0, _ := ret[0].(*dns.Msg)
+	return ret0
+}
+
+// GetStale indicates an expected call of GetStale.
+func (mr *MockCacheMockRecorder) GetStale(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStale", reflect.TypeOf((*MockCache)(nil).GetStale), arg0)
+}
+
+// Len mocks base method.
+func (m *MockCache) Len() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Len")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// Len indicates an expected call of Len.
+func (mr *MockCacheMockRecorder) Len() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Len", reflect.TypeOf((*MockCache)(nil).Len))
+}
+
+// Load mocks base method.
+func (m *MockCache) Load(arg0 io.Reader) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Load", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Load indicates an expected call of Load.
+func (mr *MockCacheMockRecorder) Load(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Load", reflect.TypeOf((*MockCache)(nil).Load), arg0)
+}
+
+// Range mocks base method.
+func (m *MockCache) Range(arg0 func(lru.Entry) bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Range", arg0)
+}
+
+// Range indicates an expected call of Range.
+func (mr *MockCacheMockRecorder) Range(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Range", reflect.TypeOf((*MockCache)(nil).Range), arg0)
+}
+
+// Remove mocks base method.
+func (m *MockCache) Remove(arg0 string, arg1 bool) int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Remove", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// Remove indicates an expected call of Remove.
+func (mr *MockCacheMockRecorder) Remove(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Remove", reflect.TypeOf((*MockCache)(nil).Remove), arg0, arg1)
+}
+
+// Save mocks base method.
+func (m *MockCache) Save(arg0 io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockCacheMockRecorder) Save(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockCache)(nil).Save), arg0)
+}
@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"strconv"
 	"strings"
 
 	"github.com/qdm12/dns/pkg/cache/lru"
@@ -8,7 +9,17 @@ import (
 
 type Settings struct {
 	Type Type
-	LRU  lru.Settings
+	// LRU contains the LRU settings, used for both the lru and
+	// sharded-lru types. For the sharded-lru type, the max entries
+	// and max bytes are split between the shards.
+	LRU lru.Settings
+	// Shards is the number of shards for the sharded-lru type,
+	// and defaults to 16.
+	Shards int
+	// SnapshotPath is the file path to save the cache to on
+	// shutdown and to load it from on start. It defaults to
+	// the empty string, disabling cache persistence.
+	SnapshotPath string
 }
 
 func (s *Settings) SetDefaults() {
@@ -20,6 +31,12 @@ func (s *Settings) SetDefaults() {
 	case Disabled:
 	case LRU:
 		s.LRU.SetDefaults()
+	case ShardedLRU:
+		s.LRU.SetDefaults()
+		if s.Shards == 0 {
+			const defaultShards = 16
+			s.Shards = defaultShards
+		}
 	}
 }
 
@@ -38,10 +55,19 @@ func (s *Settings) Lines(indent, subSection string) (lines []string) {
 	case LRU:
 		lruLines := s.LRU.Lines(indent, subSection)
 		lines = append(lines, lruLines...)
+	case ShardedLRU:
+		lines = append(lines, subSection+"Shards: "+strconv.Itoa(s.Shards))
+		lruLines := s.LRU.Lines(indent, subSection)
+		lines = append(lines, lruLines...)
 	case Disabled:
+		return lines
 	default:
 		lines = append(lines, subSection+"MISSING CODE PATH, PLEASE ADD ME!!")
 	}
 
+	if s.SnapshotPath != "" {
+		lines = append(lines, subSection+"Snapshot file: "+s.SnapshotPath)
+	}
+
 	return lines
 }
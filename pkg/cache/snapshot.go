@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SaveFile writes a snapshot of the cache to the file path given.
+// The snapshot is first written to a temporary file in the same
+// directory, which is then renamed, so an existing snapshot file is
+// never left partially written.
+func SaveFile(cache Cache, path string) (err error) {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("cannot create cache snapshot file: %w", err)
+	}
+	tempPath := file.Name()
+
+	err = cache.Save(file)
+	if err != nil {
+		_ = file.Close()
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("cannot save cache snapshot: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("cannot close cache snapshot file: %w", err)
+	}
+
+	if err := os.Rename(tempPath, path); err != nil {
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("cannot rename cache snapshot file: %w", err)
+	}
+
+	return nil
+}
+
+// LoadFile loads the cache entries from the snapshot file path given.
+// It does nothing if the file does not exist.
+func LoadFile(cache Cache, path string) (err error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("cannot open cache snapshot file: %w", err)
+	}
+
+	err = cache.Load(file)
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("cannot close cache snapshot file: %w", closeErr)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot load cache snapshot: %w", err)
+	}
+
+	return nil
+}
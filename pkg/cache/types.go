@@ -9,13 +9,15 @@ import (
 type Type string
 
 const (
-	LRU      Type = "lru"
-	Disabled Type = "disabled"
+	LRU        Type = "lru"
+	ShardedLRU Type = "sharded-lru"
+	Disabled   Type = "disabled"
 )
 
 func ListTypes() (types []Type) {
 	return []Type{
 		LRU,
+		ShardedLRU,
 		Disabled,
 	}
 }
@@ -0,0 +1,48 @@
+package dnssec
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+var (
+	ErrTrustAnchorNotDS   = errors.New("trust anchor is not a DS record")
+	ErrTrustAnchorNotRoot = errors.New("trust anchor is not for the root zone")
+	ErrNoTrustAnchor      = errors.New("no trust anchor found")
+)
+
+// ParseTrustAnchors parses root trust anchors DS records
+// in presentation format, one per line, such as the ones
+// written to the Unbound root.key file.
+// Empty lines and lines starting with ; are ignored.
+func ParseTrustAnchors(lines []string) (anchors []*dns.DS, err error) {
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		rr, err := dns.NewRR(line)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse trust anchor: %w", err)
+		}
+
+		ds, ok := rr.(*dns.DS)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrTrustAnchorNotDS, line)
+		} else if ds.Hdr.Name != "." {
+			return nil, fmt.Errorf("%w: %s", ErrTrustAnchorNotRoot, line)
+		}
+
+		anchors = append(anchors, ds)
+	}
+
+	if len(anchors) == 0 {
+		return nil, ErrNoTrustAnchor
+	}
+
+	return anchors, nil
+}
@@ -0,0 +1,54 @@
+package dnssec
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseTrustAnchors(t *testing.T) {
+	t.Parallel()
+
+	const rootDS = ". 172800 IN DS 20326 8 2 " +
+		"E06D44B80B8F1D39A95C0B0D7C65D08458E880409BBC683457104237C7F8EC8D"
+
+	testCases := map[string]struct {
+		lines      []string
+		anchors    int
+		errWrapped error
+	}{
+		"valid": {
+			lines:   []string{"; autotrust trust anchor file", "", rootDS},
+			anchors: 1,
+		},
+		"no anchor": {
+			lines:      []string{"; comment"},
+			errWrapped: ErrNoTrustAnchor,
+		},
+		"not DS": {
+			lines:      []string{". 172800 IN NS a.root-servers.net."},
+			errWrapped: ErrTrustAnchorNotDS,
+		},
+		"not root": {
+			lines: []string{"com. 86400 IN DS 19718 13 2 " +
+				"8ACBB0CD28F41250A80A491389424D341522D946B0DA0C0291F2D3D771D7805A"},
+			errWrapped: ErrTrustAnchorNotRoot,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			anchors, err := ParseTrustAnchors(testCase.lines)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			assert.Len(t, anchors, testCase.anchors)
+			for _, anchor := range anchors {
+				assert.Equal(t, uint16(dns.TypeDS), anchor.Hdr.Rrtype)
+			}
+		})
+	}
+}
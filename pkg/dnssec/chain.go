@@ -0,0 +1,320 @@
+package dnssec
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// zone is a zone found walking the chain of trust.
+type zone struct {
+	name string
+	keys []*dns.DNSKEY // nil if the zone is insecure
+}
+
+type delegationKind uint8
+
+const (
+	// delegationNone is for a name without zone cut,
+	// which belongs to the same zone as its parent.
+	delegationNone delegationKind = iota
+	// delegationSecure is for a signed child zone.
+	delegationSecure
+	// delegationInsecure is for an unsigned child zone.
+	delegationInsecure
+	// delegationNXDomain is for a name which does not exist.
+	delegationNXDomain
+)
+
+type delegation struct {
+	kind    delegationKind
+	keys    []*dns.DNSKEY // for delegationSecure only
+	expires time.Time
+}
+
+const (
+	// maxCacheDuration is the maximum duration to cache
+	// chain of trust results for.
+	maxCacheDuration = time.Hour
+	// maxCacheEntries is the maximum number of chain of
+	// trust results to cache.
+	maxCacheEntries = 10000
+)
+
+// findZone walks the chain of trust from the root zone down to the
+// name given, and returns the deepest zone containing the name.
+// The zone keys are nil if the name is in an insecure zone.
+func (v *Validator) findZone(exchange Exchange, name string) (z *zone, err error) {
+	rootKeys, err := v.rootKeys(exchange)
+	if err != nil {
+		return nil, err
+	}
+
+	z = &zone{name: ".", keys: rootKeys}
+	labels := dns.SplitDomainName(strings.ToLower(name))
+	for i := len(labels) - 1; i >= 0; i-- {
+		child := dns.Fqdn(strings.Join(labels[i:], "."))
+		delegation, err := v.getDelegation(exchange, z, child)
+		if err != nil {
+			return nil, err
+		}
+
+		switch delegation.kind {
+		case delegationNone:
+		case delegationSecure:
+			z = &zone{name: child, keys: delegation.keys}
+		case delegationInsecure:
+			return &zone{name: child}, nil
+		case delegationNXDomain:
+			return z, nil
+		}
+	}
+
+	return z, nil
+}
+
+// rootKeys returns the root zone keys, validated against the trust anchors.
+func (v *Validator) rootKeys(exchange Exchange) (keys []*dns.DNSKEY, err error) {
+	const rootCacheKey = ""
+	if delegation, ok := v.getCached(rootCacheKey); ok {
+		return delegation.keys, nil
+	}
+
+	response, err := query(exchange, ".", dns.TypeDNSKEY)
+	if err != nil {
+		return nil, err
+	}
+
+	keySet := findRRSet(groupRRSets(response.Answer), ".", dns.TypeDNSKEY)
+	if keySet == nil {
+		return nil, fmt.Errorf("%w: no root DNSKEY record", ErrBogus)
+	}
+
+	anchors := make([]dns.RR, len(v.anchors))
+	for i := range v.anchors {
+		anchors[i] = v.anchors[i]
+	}
+	anchoredKeys := matchDS(keySet.dnskeys(), anchors)
+	if len(anchoredKeys) == 0 {
+		return nil, fmt.Errorf("%w: no root DNSKEY matches the trust anchors", ErrBogus)
+	}
+
+	if err := keySet.verify(".", anchoredKeys, v.timeNow()); err != nil {
+		return nil, err
+	}
+
+	keys = keySet.dnskeys()
+	v.setCached(rootCacheKey, delegation{kind: delegationSecure, keys: keys}, response)
+	return keys, nil
+}
+
+// getDelegation returns the kind of delegation the child name is in
+// its parent zone, using the cache if possible.
+func (v *Validator) getDelegation(exchange Exchange, parent *zone,
+	child string) (d delegation, err error) {
+	if d, ok := v.getCached(child); ok {
+		return d, nil
+	}
+
+	response, err := query(exchange, child, dns.TypeDS)
+	if err != nil {
+		return d, err
+	}
+
+	d, err = v.delegation(exchange, parent, child, response)
+	if err != nil {
+		return d, err
+	}
+
+	v.setCached(child, d, response)
+	return d, nil
+}
+
+// delegation determines the kind of delegation the child name is in
+// its parent zone, using the response to the DS query for the child.
+func (v *Validator) delegation(exchange Exchange, parent *zone,
+	child string, response *dns.Msg) (d delegation, err error) {
+	now := v.timeNow()
+
+	question := dns.Question{Name: child, Qtype: dns.TypeDS, Qclass: dns.ClassINET}
+	if response.Rcode == dns.RcodeNameError {
+		err = verifyDenial(groupRRSets(response.Ns), parent, question, true, now)
+		return delegation{kind: delegationNXDomain}, err
+	}
+
+	answerSets := groupRRSets(response.Answer)
+	if dsSet := findRRSet(answerSets, child, dns.TypeDS); dsSet != nil {
+		if err := dsSet.verify(parent.name, parent.keys, now); err != nil {
+			return d, err
+		}
+
+		keys, err := v.childKeys(exchange, child, dsSet.records)
+		if err != nil {
+			return d, err
+		}
+		return delegation{kind: delegationSecure, keys: keys}, nil
+	}
+
+	if findRRSet(answerSets, child, dns.TypeCNAME) != nil {
+		// a CNAME cannot coexist with a delegation
+		return delegation{kind: delegationNone}, nil
+	}
+
+	authoritySets := groupRRSets(response.Ns)
+	if err := verifyDenial(authoritySets, parent, question, false, now); err != nil {
+		return d, err
+	}
+
+	for _, set := range authoritySets {
+		for _, rr := range set.records {
+			if isInsecureDelegation(rr, child) {
+				return delegation{kind: delegationInsecure}, nil
+			}
+		}
+	}
+
+	return delegation{kind: delegationNone}, nil
+}
+
+// childKeys returns the DNSKEY records of the child zone,
+// validated using the DS records of the parent zone.
+func (v *Validator) childKeys(exchange Exchange, child string,
+	dsRecords []dns.RR) (keys []*dns.DNSKEY, err error) {
+	response, err := query(exchange, child, dns.TypeDNSKEY)
+	if err != nil {
+		return nil, err
+	}
+
+	keySet := findRRSet(groupRRSets(response.Answer), child, dns.TypeDNSKEY)
+	if keySet == nil {
+		return nil, fmt.Errorf("%w: no DNSKEY record for %s", ErrBogus, child)
+	}
+
+	matchedKeys := matchDS(keySet.dnskeys(), dsRecords)
+	if len(matchedKeys) == 0 {
+		return nil, fmt.Errorf("%w: no DNSKEY matches the DS records of %s", ErrBogus, child)
+	}
+
+	if err := keySet.verify(child, matchedKeys, v.timeNow()); err != nil {
+		return nil, err
+	}
+
+	return keySet.dnskeys(), nil
+}
+
+// isInsecureDelegation returns true if the NSEC or NSEC3 record given
+// proves the child name is a delegation to an unsigned zone.
+func isInsecureDelegation(rr dns.RR, child string) bool {
+	switch record := rr.(type) {
+	case *dns.NSEC:
+		return strings.EqualFold(record.Hdr.Name, child) &&
+			isUnsignedDelegation(record.TypeBitMap)
+	case *dns.NSEC3:
+		if record.Match(child) {
+			return isUnsignedDelegation(record.TypeBitMap)
+		}
+		return record.Cover(child) && record.Flags&optOutFlag != 0
+	default:
+		return false
+	}
+}
+
+// isUnsignedDelegation returns true if the NSEC or NSEC3 type bitmap
+// is the one of a delegation point without DS record.
+func isUnsignedDelegation(typeBitMap []uint16) bool {
+	var hasNS bool
+	for _, rrtype := range typeBitMap {
+		switch rrtype {
+		case dns.TypeNS:
+			hasNS = true
+		case dns.TypeDS, dns.TypeSOA:
+			return false
+		}
+	}
+	return hasNS
+}
+
+// query sends a DNSSEC query for the name and type given,
+// with the checking disabled bit set to obtain the records
+// even if the upstream server fails to validate them.
+func query(exchange Exchange, name string, qtype uint16) (response *dns.Msg, err error) {
+	request := new(dns.Msg).SetQuestion(name, qtype)
+	const udpSize = 4096
+	request.SetEdns0(udpSize, true)
+	request.CheckingDisabled = true
+
+	response, err = exchange(request)
+	if err != nil {
+		return nil, fmt.Errorf("cannot query %s %s: %w", name, dns.TypeToString[qtype], err)
+	}
+
+	switch response.Rcode {
+	case dns.RcodeSuccess, dns.RcodeNameError:
+		return response, nil
+	default:
+		return nil, fmt.Errorf("%w: %s for %s %s", ErrQueryFailed,
+			dns.RcodeToString[response.Rcode], name, dns.TypeToString[qtype])
+	}
+}
+
+func (v *Validator) getCached(name string) (d delegation, ok bool) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	d, ok = v.cache[name]
+	if ok && !v.timeNow().Before(d.expires) {
+		delete(v.cache, name)
+		return d, false
+	}
+	return d, ok
+}
+
+// setCached caches the delegation for the minimum TTL of the
+// records of the response, capped to maxCacheDuration.
+func (v *Validator) setCached(name string, d delegation, response *dns.Msg) {
+	duration := maxCacheDuration
+	for _, section := range [][]dns.RR{response.Answer, response.Ns} {
+		for _, rr := range section {
+			ttl := time.Duration(rr.Header().Ttl) * time.Second
+			if ttl < duration {
+				duration = ttl
+			}
+		}
+	}
+
+	if duration == 0 {
+		return
+	}
+
+	now := v.timeNow()
+	d.expires = now.Add(duration)
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	if _, exists := v.cache[name]; !exists && len(v.cache) >= v.maxEntries {
+		v.evict(now)
+	}
+	v.cache[name] = d
+}
+
+// evict removes the expired entries of the cache, or the entry
+// expiring the soonest if none is expired.
+// It must be called with the mutex locked.
+func (v *Validator) evict(now time.Time) {
+	var soonestName string
+	var soonest time.Time
+	for name, d := range v.cache {
+		if !now.Before(d.expires) {
+			delete(v.cache, name)
+			continue
+		}
+		if soonest.IsZero() || d.expires.Before(soonest) {
+			soonestName, soonest = name, d.expires
+		}
+	}
+
+	if len(v.cache) >= v.maxEntries {
+		delete(v.cache, soonestName)
+	}
+}
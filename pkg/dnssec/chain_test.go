@@ -0,0 +1,45 @@
+package dnssec
+
+import (
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Validator_setCached(t *testing.T) {
+	t.Parallel()
+
+	now := testNow
+	validator := NewValidator(Settings{})
+	validator.maxEntries = 2
+	validator.timeNow = func() time.Time { return now }
+
+	newTTLResponse := func(ttl uint32) *dns.Msg {
+		a := newA("example.com.", "1.2.3.4")
+		a.Hdr.Ttl = ttl
+		return newResponse("example.com.", dns.TypeA, dns.RcodeSuccess, []dns.RR{a}, nil)
+	}
+
+	validator.setCached("a.", delegation{}, newTTLResponse(60))
+	validator.setCached("b.", delegation{}, newTTLResponse(30))
+	validator.setCached("c.", delegation{}, newTTLResponse(90))
+
+	// b. expires the soonest and is evicted
+	assert.Len(t, validator.cache, 2)
+	assert.Contains(t, validator.cache, "a.")
+	assert.Contains(t, validator.cache, "c.")
+
+	// a. is expired and is evicted
+	now = now.Add(time.Minute)
+	validator.setCached("d.", delegation{}, newTTLResponse(10))
+	assert.Len(t, validator.cache, 2)
+	assert.Contains(t, validator.cache, "c.")
+	assert.Contains(t, validator.cache, "d.")
+
+	// updating an existing entry does not evict
+	validator.setCached("c.", delegation{}, newTTLResponse(10))
+	assert.Len(t, validator.cache, 2)
+	assert.Contains(t, validator.cache, "d.")
+}
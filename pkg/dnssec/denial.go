@@ -0,0 +1,307 @@
+package dnssec
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// verifyDenial verifies the NSEC or NSEC3 RRsets given are signed
+// by the zone and prove the question name does not exist if nxdomain
+// is true, or that the question type does not exist for the question
+// name otherwise, as described in RFC 4035 section 5.4 for NSEC
+// records and RFC 5155 section 8 for NSEC3 records.
+func verifyDenial(sets []*rrset, z *zone, question dns.Question,
+	nxdomain bool, now time.Time) (err error) {
+	var nsecs []*dns.NSEC
+	var nsec3s []*dns.NSEC3
+	for _, set := range sets {
+		if set.rrtype != dns.TypeNSEC && set.rrtype != dns.TypeNSEC3 {
+			continue
+		}
+
+		if err := set.verify(z.name, z.keys, now); err != nil {
+			return err
+		}
+
+		for _, rr := range set.records {
+			switch record := rr.(type) {
+			case *dns.NSEC:
+				nsecs = append(nsecs, record)
+			case *dns.NSEC3:
+				if record.Hash == dns.SHA1 {
+					nsec3s = append(nsec3s, record)
+				}
+			}
+		}
+	}
+
+	var proven bool
+	switch {
+	case len(nsecs) > 0:
+		proven = nsecDenial(nsecs, question, nxdomain)
+	case len(nsec3s) > 0:
+		proven = nsec3Denial(nsec3s, z.name, question, nxdomain)
+	default:
+		return fmt.Errorf("%w: no authenticated denial of existence from zone %s",
+			ErrBogus, z.name)
+	}
+
+	if !proven {
+		return fmt.Errorf("%w: denial of existence from zone %s does not prove %s",
+			ErrBogus, z.name, denialString(question, nxdomain))
+	}
+	return nil
+}
+
+func denialString(question dns.Question, nxdomain bool) string {
+	if nxdomain {
+		return "there is no name " + question.Name
+	}
+	return "there is no " + dns.TypeToString[question.Qtype] +
+		" record for " + question.Name
+}
+
+// nsecDenial returns true if the NSEC records prove the denial
+// of existence of the question name or type.
+func nsecDenial(nsecs []*dns.NSEC, question dns.Question, nxdomain bool) bool {
+	name := question.Name
+
+	if !nxdomain {
+		for _, nsec := range nsecs {
+			if strings.EqualFold(nsec.Hdr.Name, name) {
+				return !hasType(nsec.TypeBitMap, question.Qtype) &&
+					!hasType(nsec.TypeBitMap, dns.TypeCNAME) &&
+					(question.Qtype == dns.TypeDS || !isNSECDelegation(nsec.TypeBitMap))
+			}
+		}
+	}
+
+	covering := nsecCovering(nsecs, name)
+	if covering == nil {
+		return false
+	}
+
+	if !nxdomain && dns.IsSubDomain(name, covering.NextDomain) {
+		// the name is an empty non-terminal
+		return true
+	}
+
+	wildcard := wildcardName(nsecClosestEncloser(covering, name))
+	for _, nsec := range nsecs {
+		if nxdomain && nsecCovers(nsec, wildcard) && !isAncestorCut(nsec, wildcard) {
+			return true
+		}
+		if !nxdomain && strings.EqualFold(nsec.Hdr.Name, wildcard) {
+			return !hasType(nsec.TypeBitMap, question.Qtype) &&
+				!hasType(nsec.TypeBitMap, dns.TypeCNAME)
+		}
+	}
+	return false
+}
+
+// nsecCovering returns the NSEC record covering the name, or nil.
+// Records owned by a zone cut above the name are ignored, since
+// the names below a zone cut are not in the zone.
+func nsecCovering(nsecs []*dns.NSEC, name string) *dns.NSEC {
+	for _, nsec := range nsecs {
+		if nsecCovers(nsec, name) && !isAncestorCut(nsec, name) {
+			return nsec
+		}
+	}
+	return nil
+}
+
+// isAncestorCut returns true if the NSEC record is owned by an
+// ancestor of the name being a delegation point or a DNAME owner,
+// as described in RFC 6840 section 4.1.
+func isAncestorCut(nsec *dns.NSEC, name string) bool {
+	owner := nsec.Hdr.Name
+	return !strings.EqualFold(owner, name) && dns.IsSubDomain(owner, name) &&
+		(isNSECDelegation(nsec.TypeBitMap) || hasType(nsec.TypeBitMap, dns.TypeDNAME))
+}
+
+// nsecCovers returns true if the name is strictly between the
+// owner name and the next domain name of the NSEC record in
+// canonical order.
+func nsecCovers(nsec *dns.NSEC, name string) bool {
+	owner, next := nsec.Hdr.Name, nsec.NextDomain
+	if canonicalCompare(owner, next) < 0 {
+		return canonicalCompare(owner, name) < 0 &&
+			canonicalCompare(name, next) < 0
+	}
+	// last NSEC record of the zone, with the next name being the apex
+	return canonicalCompare(owner, name) < 0 ||
+		canonicalCompare(name, next) < 0
+}
+
+// nsecClosestEncloser returns the closest encloser of the name
+// given the NSEC record covering it, which is the longest common
+// ancestor of the name with the owner or next names of the record.
+func nsecClosestEncloser(covering *dns.NSEC, name string) (closestEncloser string) {
+	commonLabels := dns.CompareDomainName(name, covering.Hdr.Name)
+	if n := dns.CompareDomainName(name, covering.NextDomain); n > commonLabels {
+		commonLabels = n
+	}
+	labels := dns.SplitDomainName(name)
+	return dns.Fqdn(strings.Join(labels[len(labels)-commonLabels:], "."))
+}
+
+// isNSECDelegation returns true if the type bitmap is the one of
+// a delegation point in the parent zone, which cannot be used to
+// deny the existence of types other than DS.
+func isNSECDelegation(typeBitMap []uint16) bool {
+	return hasType(typeBitMap, dns.TypeNS) && !hasType(typeBitMap, dns.TypeSOA)
+}
+
+// nsec3Denial returns true if the NSEC3 records prove the denial
+// of existence of the question name or type in the zone.
+func nsec3Denial(nsec3s []*dns.NSEC3, zone string,
+	question dns.Question, nxdomain bool) bool {
+	name := question.Name
+
+	if !nxdomain {
+		if nsec3 := nsec3Match(nsec3s, name); nsec3 != nil {
+			return !hasType(nsec3.TypeBitMap, question.Qtype) &&
+				!hasType(nsec3.TypeBitMap, dns.TypeCNAME) &&
+				(question.Qtype == dns.TypeDS || !isNSECDelegation(nsec3.TypeBitMap))
+		}
+	}
+
+	closestEncloser, nextCloserCover, ok := nsec3ClosestEncloser(nsec3s, zone, name)
+	if !ok {
+		return false
+	}
+
+	wildcard := wildcardName(closestEncloser)
+	switch {
+	case nxdomain:
+		return nsec3Cover(nsec3s, wildcard) != nil
+	case question.Qtype == dns.TypeDS && nextCloserCover.Flags&optOutFlag != 0:
+		// the name may be an unsigned delegation in an opt-out span
+		return true
+	default:
+		nsec3 := nsec3Match(nsec3s, wildcard)
+		return nsec3 != nil &&
+			!hasType(nsec3.TypeBitMap, question.Qtype) &&
+			!hasType(nsec3.TypeBitMap, dns.TypeCNAME)
+	}
+}
+
+const optOutFlag = 1
+
+// nsec3ClosestEncloser returns the closest encloser of the name,
+// which is its longest ancestor in the zone matched by an NSEC3
+// record and for which the next closer name is covered by an NSEC3
+// record, returned as well. It returns ok as false if no closest
+// encloser proof is found, or if the closest encloser is a zone cut.
+func nsec3ClosestEncloser(nsec3s []*dns.NSEC3, zone, name string) (
+	closestEncloser string, nextCloserCover *dns.NSEC3, ok bool) {
+	labels := dns.SplitDomainName(name)
+	for i := 1; i <= len(labels); i++ {
+		candidate := dns.Fqdn(strings.Join(labels[i:], "."))
+		if !dns.IsSubDomain(zone, candidate) {
+			break
+		}
+
+		match := nsec3Match(nsec3s, candidate)
+		if match == nil {
+			continue
+		}
+
+		// RFC 5155 section 8.3: the closest encloser
+		// cannot be a delegation point or a DNAME owner.
+		if isNSECDelegation(match.TypeBitMap) || hasType(match.TypeBitMap, dns.TypeDNAME) {
+			return "", nil, false
+		}
+
+		nextCloser := dns.Fqdn(strings.Join(labels[i-1:], "."))
+		nextCloserCover = nsec3Cover(nsec3s, nextCloser)
+		return candidate, nextCloserCover, nextCloserCover != nil
+	}
+	return "", nil, false
+}
+
+func nsec3Match(nsec3s []*dns.NSEC3, name string) *dns.NSEC3 {
+	for _, nsec3 := range nsec3s {
+		if nsec3.Match(name) {
+			return nsec3
+		}
+	}
+	return nil
+}
+
+// nsec3Cover returns the NSEC3 record covering the hash of the name,
+// excluding records matching it.
+func nsec3Cover(nsec3s []*dns.NSEC3, name string) *dns.NSEC3 {
+	for _, nsec3 := range nsec3s {
+		if nsec3.Cover(name) && !nsec3.Match(name) {
+			return nsec3
+		}
+	}
+	return nil
+}
+
+// wildcardName returns the wildcard name directly below the name given.
+func wildcardName(name string) string {
+	if name == "." {
+		return "*."
+	}
+	return "*." + name
+}
+
+func hasType(typeBitMap []uint16, rrtype uint16) bool {
+	for _, t := range typeBitMap {
+		if t == rrtype {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalCompare compares the two names in the canonical
+// DNS name order defined in RFC 4034 section 6.1, returning
+// a negative number if a sorts before b, 0 if they are equal
+// and a positive number if a sorts after b.
+func canonicalCompare(a, b string) int {
+	aLabels := dns.SplitDomainName(a)
+	bLabels := dns.SplitDomainName(b)
+	for i := 1; i <= len(aLabels) && i <= len(bLabels); i++ {
+		result := bytes.Compare(
+			canonicalLabel(aLabels[len(aLabels)-i]),
+			canonicalLabel(bLabels[len(bLabels)-i]))
+		if result != 0 {
+			return result
+		}
+	}
+	return len(aLabels) - len(bLabels)
+}
+
+// canonicalLabel returns the wire format bytes of the label given in
+// presentation format, with its uppercase ASCII letters lowercased.
+func canonicalLabel(label string) (b []byte) {
+	b = make([]byte, 0, len(label))
+	for i := 0; i < len(label); i++ {
+		c := label[i]
+		if c == '\\' && i+1 < len(label) {
+			i++
+			c = label[i]
+			const decimalLength = 3
+			if i+decimalLength <= len(label) {
+				if n, err := strconv.ParseUint(label[i:i+decimalLength], 10, 8); err == nil {
+					c = byte(n)
+					i += decimalLength - 1
+				}
+			}
+		}
+		if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		b = append(b, c)
+	}
+	return b
+}
@@ -0,0 +1,201 @@
+package dnssec
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+)
+
+func newNSEC3(zone, name, next string, flags uint8, types ...uint16) *dns.NSEC3 {
+	hash := func(name string) string {
+		return dns.HashName(name, dns.SHA1, 0, "")
+	}
+	return &dns.NSEC3{
+		Hdr:        header(strings.ToLower(hash(name))+"."+zone, dns.TypeNSEC3),
+		Hash:       dns.SHA1,
+		Flags:      flags,
+		HashLength: 20, //nolint:gomnd
+		NextDomain: hash(next),
+		TypeBitMap: types,
+	}
+}
+
+func Test_verifyDenial(t *testing.T) {
+	t.Parallel()
+
+	example := newTestZone(t, "example.com.")
+	z := &zone{name: example.name, keys: []*dns.DNSKEY{example.key}}
+
+	apexNSEC := newNSEC("example.com.", "www.example.com.",
+		dns.TypeNS, dns.TypeSOA, dns.TypeRRSIG, dns.TypeNSEC, dns.TypeDNSKEY)
+	wwwNSEC := newNSEC("www.example.com.", "example.com.",
+		dns.TypeA, dns.TypeRRSIG, dns.TypeNSEC)
+	delegationNSEC := newNSEC("sub.example.com.", "www.example.com.",
+		dns.TypeNS, dns.TypeRRSIG, dns.TypeNSEC)
+	nsec3Chain := func(flags uint8) []dns.RR {
+		return []dns.RR{
+			newNSEC3("example.com.", "example.com.", "www.example.com.", flags,
+				dns.TypeNS, dns.TypeSOA, dns.TypeRRSIG, dns.TypeDNSKEY, dns.TypeNSEC3PARAM),
+			newNSEC3("example.com.", "www.example.com.", "example.com.", flags,
+				dns.TypeA, dns.TypeRRSIG),
+		}
+	}
+	sign := func(rrs ...dns.RR) (signed []dns.RR) {
+		for _, rr := range rrs {
+			signed = append(signed, example.sign(t, rr)...)
+		}
+		return signed
+	}
+
+	testCases := map[string]struct {
+		ns         []dns.RR
+		question   dns.Question
+		nxdomain   bool
+		errMessage string
+	}{
+		"no denial record": {
+			question:   dns.Question{Name: "nx.example.com.", Qtype: dns.TypeA},
+			nxdomain:   true,
+			errMessage: "DNSSEC validation failed: no authenticated denial of existence from zone example.com.",
+		},
+		"unsigned NSEC": {
+			ns:         []dns.RR{apexNSEC},
+			question:   dns.Question{Name: "b.example.com.", Qtype: dns.TypeA},
+			nxdomain:   true,
+			errMessage: "DNSSEC validation failed: example.com. NSEC: no signature from example.com.",
+		},
+		"NSEC NXDOMAIN": {
+			ns:       sign(apexNSEC, wwwNSEC),
+			question: dns.Question{Name: "b.example.com.", Qtype: dns.TypeA},
+			nxdomain: true,
+		},
+		"NSEC NXDOMAIN without wildcard proof": {
+			ns:       sign(wwwNSEC, newNSEC("a.example.com.", "www.example.com.", dns.TypeA)),
+			question: dns.Question{Name: "b.example.com.", Qtype: dns.TypeA},
+			nxdomain: true,
+			errMessage: "DNSSEC validation failed: denial of existence from zone example.com. " +
+				"does not prove there is no name b.example.com.",
+		},
+		"NSEC NXDOMAIN for existing name": {
+			ns:       sign(apexNSEC, wwwNSEC),
+			question: dns.Question{Name: "www.example.com.", Qtype: dns.TypeA},
+			nxdomain: true,
+			errMessage: "DNSSEC validation failed: denial of existence from zone example.com. " +
+				"does not prove there is no name www.example.com.",
+		},
+		"NSEC NODATA": {
+			ns:       sign(wwwNSEC),
+			question: dns.Question{Name: "www.example.com.", Qtype: dns.TypeAAAA},
+		},
+		"NSEC NODATA with CNAME": {
+			ns:       sign(newNSEC("www.example.com.", "example.com.", dns.TypeCNAME)),
+			question: dns.Question{Name: "www.example.com.", Qtype: dns.TypeAAAA},
+			errMessage: "DNSSEC validation failed: denial of existence from zone example.com. " +
+				"does not prove there is no AAAA record for www.example.com.",
+		},
+		"NSEC NODATA for empty non-terminal": {
+			ns:       sign(newNSEC("example.com.", "a.b.example.com.", dns.TypeSOA)),
+			question: dns.Question{Name: "b.example.com.", Qtype: dns.TypeA},
+		},
+		"NSEC NODATA from wildcard": {
+			ns: sign(apexNSEC, wwwNSEC,
+				newNSEC("*.example.com.", "www.example.com.", dns.TypeA)),
+			question: dns.Question{Name: "b.example.com.", Qtype: dns.TypeAAAA},
+		},
+		"NSEC DS NODATA at delegation": {
+			ns:       sign(delegationNSEC),
+			question: dns.Question{Name: "sub.example.com.", Qtype: dns.TypeDS},
+		},
+		"NSEC NODATA at delegation": {
+			ns:       sign(delegationNSEC),
+			question: dns.Question{Name: "sub.example.com.", Qtype: dns.TypeA},
+			errMessage: "DNSSEC validation failed: denial of existence from zone example.com. " +
+				"does not prove there is no A record for sub.example.com.",
+		},
+		"NSEC NXDOMAIN below a delegation": {
+			ns:       sign(delegationNSEC),
+			question: dns.Question{Name: "a.sub.example.com.", Qtype: dns.TypeA},
+			nxdomain: true,
+			errMessage: "DNSSEC validation failed: denial of existence from zone example.com. " +
+				"does not prove there is no name a.sub.example.com.",
+		},
+		"NSEC3 NXDOMAIN below a delegation": {
+			ns: sign(
+				newNSEC3("example.com.", "example.com.", "sub.example.com.", 0,
+					dns.TypeNS, dns.TypeSOA, dns.TypeRRSIG, dns.TypeDNSKEY, dns.TypeNSEC3PARAM),
+				newNSEC3("example.com.", "sub.example.com.", "example.com.", 0,
+					dns.TypeNS),
+			),
+			question: dns.Question{Name: "a.sub.example.com.", Qtype: dns.TypeA},
+			nxdomain: true,
+			errMessage: "DNSSEC validation failed: denial of existence from zone example.com. " +
+				"does not prove there is no name a.sub.example.com.",
+		},
+		"NSEC3 NXDOMAIN": {
+			ns:       sign(nsec3Chain(0)...),
+			question: dns.Question{Name: "nx.example.com.", Qtype: dns.TypeA},
+			nxdomain: true,
+		},
+		"NSEC3 NXDOMAIN for existing name": {
+			ns:       sign(nsec3Chain(0)...),
+			question: dns.Question{Name: "www.example.com.", Qtype: dns.TypeA},
+			nxdomain: true,
+			errMessage: "DNSSEC validation failed: denial of existence from zone example.com. " +
+				"does not prove there is no name www.example.com.",
+		},
+		"NSEC3 NODATA": {
+			ns:       sign(nsec3Chain(0)...),
+			question: dns.Question{Name: "www.example.com.", Qtype: dns.TypeAAAA},
+		},
+		"NSEC3 NODATA denying an existing type": {
+			ns:       sign(nsec3Chain(0)...),
+			question: dns.Question{Name: "www.example.com.", Qtype: dns.TypeA},
+			errMessage: "DNSSEC validation failed: denial of existence from zone example.com. " +
+				"does not prove there is no A record for www.example.com.",
+		},
+		"NSEC3 DS NODATA with opt-out": {
+			ns:       sign(nsec3Chain(optOutFlag)...),
+			question: dns.Question{Name: "sub.example.com.", Qtype: dns.TypeDS},
+		},
+		"NSEC3 DS NODATA without opt-out": {
+			ns:       sign(nsec3Chain(0)...),
+			question: dns.Question{Name: "sub.example.com.", Qtype: dns.TypeDS},
+			errMessage: "DNSSEC validation failed: denial of existence from zone example.com. " +
+				"does not prove there is no DS record for sub.example.com.",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := verifyDenial(groupRRSets(testCase.ns), z,
+				testCase.question, testCase.nxdomain, testNow)
+
+			if testCase.errMessage != "" {
+				assert.ErrorIs(t, err, ErrBogus)
+				assert.EqualError(t, err, testCase.errMessage)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_canonicalCompare(t *testing.T) {
+	t.Parallel()
+
+	// ordered example from RFC 4034 section 6.1
+	names := []string{
+		"example.", "a.example.", "yljkjljk.a.example.", "Z.a.example.",
+		"zABC.a.EXAMPLE.", "z.example.", "\\001.z.example.", "*.z.example.",
+	}
+	for i := 0; i < len(names)-1; i++ {
+		assert.Negative(t, canonicalCompare(names[i], names[i+1]), names[i])
+		assert.Positive(t, canonicalCompare(names[i+1], names[i]), names[i])
+	}
+	assert.Zero(t, canonicalCompare("Example.com.", "example.COM."))
+}
@@ -0,0 +1,137 @@
+package dnssec
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// rrset is a set of records with the same owner name and type,
+// with the RRSIG records covering it.
+type rrset struct {
+	name    string // lowercased
+	rrtype  uint16
+	records []dns.RR
+	sigs    []*dns.RRSIG
+}
+
+// groupRRSets groups the records given by RRset, in their order
+// of appearance, and attaches the RRSIG records covering each
+// RRset. RRSIG records not covering any record are ignored.
+func groupRRSets(rrs []dns.RR) (sets []*rrset) {
+	type key struct {
+		name   string
+		rrtype uint16
+	}
+	keyToSet := make(map[key]*rrset)
+
+	getSet := func(name string, rrtype uint16) *rrset {
+		k := key{name: strings.ToLower(name), rrtype: rrtype}
+		set, ok := keyToSet[k]
+		if !ok {
+			set = &rrset{name: k.name, rrtype: rrtype}
+			keyToSet[k] = set
+			sets = append(sets, set)
+		}
+		return set
+	}
+
+	for _, rr := range rrs {
+		header := rr.Header()
+		switch record := rr.(type) {
+		case *dns.RRSIG:
+			set := getSet(header.Name, record.TypeCovered)
+			set.sigs = append(set.sigs, record)
+		case *dns.OPT:
+		default:
+			set := getSet(header.Name, header.Rrtype)
+			set.records = append(set.records, rr)
+		}
+	}
+
+	// Remove sets with signatures only
+	filtered := sets[:0]
+	for _, set := range sets {
+		if len(set.records) > 0 {
+			filtered = append(filtered, set)
+		}
+	}
+	return filtered
+}
+
+func (s *rrset) String() string {
+	return s.name + " " + dns.TypeToString[s.rrtype]
+}
+
+// dnskeys returns the DNSKEY records of the RRset.
+func (s *rrset) dnskeys() (keys []*dns.DNSKEY) {
+	for _, rr := range s.records {
+		if key, ok := rr.(*dns.DNSKEY); ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// findRRSet returns the RRset for the name and type given, or nil.
+func findRRSet(sets []*rrset, name string, rrtype uint16) *rrset {
+	name = strings.ToLower(name)
+	for _, set := range sets {
+		if set.name == name && set.rrtype == rrtype {
+			return set
+		}
+	}
+	return nil
+}
+
+// verify returns nil if one of the signatures of the RRset made by
+// the signer zone is currently valid and verifies with one of the
+// keys given. It returns an error wrapping ErrBogus otherwise.
+func (s *rrset) verify(signer string, keys []*dns.DNSKEY, now time.Time) (err error) {
+	reason := "no signature from " + signer
+	for _, sig := range s.sigs {
+		if !strings.EqualFold(sig.SignerName, signer) {
+			continue
+		}
+
+		if !sig.ValidityPeriod(now) {
+			reason = "signature is expired or not yet valid"
+			continue
+		}
+
+		for _, key := range keys {
+			if key.KeyTag() != sig.KeyTag || key.Algorithm != sig.Algorithm {
+				continue
+			}
+
+			err := sig.Verify(key, s.records)
+			if err == nil {
+				return nil
+			}
+			reason = err.Error()
+		}
+	}
+
+	return fmt.Errorf("%w: %s: %s", ErrBogus, s, reason)
+}
+
+// matchDS returns the keys matching one of the DS records given.
+func matchDS(keys []*dns.DNSKEY, dsRecords []dns.RR) (matched []*dns.DNSKEY) {
+	for _, key := range keys {
+		for _, rr := range dsRecords {
+			ds, ok := rr.(*dns.DS)
+			if !ok || key.KeyTag() != ds.KeyTag || key.Algorithm != ds.Algorithm {
+				continue
+			}
+
+			keyDS := key.ToDS(ds.DigestType)
+			if keyDS != nil && strings.EqualFold(keyDS.Digest, ds.Digest) {
+				matched = append(matched, key)
+				break
+			}
+		}
+	}
+	return matched
+}
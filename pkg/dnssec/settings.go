@@ -0,0 +1,34 @@
+package dnssec
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+type Settings struct {
+	// Enabled enables DNSSEC validation of upstream responses.
+	Enabled bool
+	// TrustAnchors are the DS records of the root zone key signing
+	// keys the chains of trust are validated against.
+	TrustAnchors []*dns.DS
+}
+
+func (s *Settings) String() string {
+	const (
+		subSection = " |--"
+		indent     = "    " // used if lines already contain the subSection
+	)
+	return strings.Join(s.Lines(indent, subSection), "\n")
+}
+
+func (s *Settings) Lines(indent, subSection string) (lines []string) {
+	if !s.Enabled {
+		return []string{subSection + "DNSSEC validation is disabled"}
+	}
+
+	lines = append(lines, subSection+"Trust anchors: "+strconv.Itoa(len(s.TrustAnchors)))
+
+	return lines
+}
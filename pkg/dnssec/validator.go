@@ -0,0 +1,223 @@
+package dnssec
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+var (
+	ErrBogus       = errors.New("DNSSEC validation failed")
+	ErrQueryFailed = errors.New("DNSSEC query failed")
+)
+
+// Exchange sends the request to an upstream server and returns its response.
+type Exchange func(request *dns.Msg) (response *dns.Msg, err error)
+
+// Validator validates DNSSEC signed responses by building the
+// chain of trust from the root trust anchors down to the signer
+// zone of each RRset. Results of the chain of trust are cached
+// for the TTL of the records involved, up to an hour, and at most
+// 10000 of them are kept.
+// Negative responses are validated using the NSEC or NSEC3 records
+// proving the name or type queried does not exist.
+type Validator struct {
+	anchors    []*dns.DS
+	cache      map[string]delegation
+	maxEntries int
+	mutex      sync.Mutex
+
+	// Mock fields
+	timeNow func() time.Time
+}
+
+func NewValidator(settings Settings) *Validator {
+	return &Validator{
+		anchors:    settings.TrustAnchors,
+		cache:      make(map[string]delegation),
+		maxEntries: maxCacheEntries,
+		timeNow:    time.Now,
+	}
+}
+
+// Validate validates the response obtained from a request with the
+// DNSSEC OK bit set, using the exchange function to query the records
+// needed to build the chain of trust. It returns secure as true if
+// all the records of the response are validated, and false if some
+// of them are in insecure zones. It returns an error wrapping
+// ErrBogus if the response fails validation.
+func (v *Validator) Validate(exchange Exchange, response *dns.Msg) (
+	secure bool, err error) {
+	if len(response.Question) == 0 {
+		return false, nil
+	}
+
+	secure = true
+	for _, set := range groupRRSets(response.Answer) {
+		setSecure, err := v.validateRRSet(exchange, set)
+		if err != nil {
+			return false, err
+		}
+		secure = secure && setSecure
+	}
+
+	nxdomain := response.Rcode == dns.RcodeNameError
+	if !nxdomain && hasAnswer(response) {
+		return secure, nil
+	}
+
+	question := dns.Question{
+		Name:   targetName(response),
+		Qtype:  response.Question[0].Qtype,
+		Qclass: response.Question[0].Qclass,
+	}
+	z, err := v.findZone(exchange, question.Name)
+	if err != nil {
+		return false, err
+	} else if z.keys == nil {
+		return false, nil
+	}
+
+	authoritySets := groupRRSets(response.Ns)
+	for _, set := range authoritySets {
+		if _, err := v.validateRRSet(exchange, set); err != nil {
+			return false, err
+		}
+	}
+
+	if err := verifyDenial(authoritySets, z, question, nxdomain, v.timeNow()); err != nil {
+		return false, err
+	}
+
+	return secure, nil
+}
+
+// validateRRSet validates the RRset using the keys of its signer zone.
+// It returns secure as false if the RRset is in an insecure zone.
+func (v *Validator) validateRRSet(exchange Exchange, set *rrset) (
+	secure bool, err error) {
+	if len(set.sigs) == 0 {
+		z, err := v.findZone(exchange, set.name)
+		if err != nil {
+			return false, err
+		} else if z.keys != nil {
+			return false, fmt.Errorf("%w: %s: no signature in secure zone %s",
+				ErrBogus, set, z.name)
+		}
+		return false, nil
+	}
+
+	signer := strings.ToLower(set.sigs[0].SignerName)
+	if !dns.IsSubDomain(signer, set.name) {
+		return false, fmt.Errorf("%w: %s: signer %s is not a parent zone",
+			ErrBogus, set, signer)
+	}
+
+	z, err := v.findZone(exchange, signer)
+	if err != nil {
+		return false, err
+	} else if z.keys == nil {
+		return false, nil
+	} else if z.name != signer {
+		return false, fmt.Errorf("%w: %s: signer %s is not a secure zone",
+			ErrBogus, set, signer)
+	}
+
+	if err := set.verify(signer, z.keys, v.timeNow()); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// hasAnswer returns true if the answer section contains
+// a record of the question type.
+func hasAnswer(response *dns.Msg) bool {
+	qtype := response.Question[0].Qtype
+	for _, rr := range response.Answer {
+		if rr.Header().Rrtype == qtype {
+			return true
+		}
+	}
+	return false
+}
+
+// targetName returns the question name of the response,
+// following the CNAME records of its answer section.
+func targetName(response *dns.Msg) (name string) {
+	name = response.Question[0].Name
+	for range response.Answer { // bound the number of CNAMEs followed
+		found := false
+		for _, rr := range response.Answer {
+			cname, ok := rr.(*dns.CNAME)
+			if ok && strings.EqualFold(cname.Hdr.Name, name) {
+				name = cname.Target
+				found = true
+				break
+			}
+		}
+		if !found {
+			break
+		}
+	}
+	return name
+}
+
+// WithDO returns a copy of the request with the DNSSEC OK bit set,
+// adding an EDNS0 OPT record to it if needed.
+func WithDO(request *dns.Msg) (upstreamRequest *dns.Msg) {
+	upstreamRequest = request.Copy()
+	if opt := upstreamRequest.IsEdns0(); opt != nil {
+		opt.SetDo()
+		return upstreamRequest
+	}
+	const udpSize = 4096
+	upstreamRequest.SetEdns0(udpSize, true)
+	return upstreamRequest
+}
+
+// Strip removes the DNSSEC records of the response not explicitly
+// queried if the request does not have the DNSSEC OK bit set, and
+// removes the OPT record of the response if the request has none.
+func Strip(request, response *dns.Msg) {
+	opt := request.IsEdns0()
+	if opt != nil && opt.Do() {
+		return
+	}
+
+	var qtype uint16
+	if len(request.Question) > 0 {
+		qtype = request.Question[0].Qtype
+	}
+
+	response.Answer = stripRecords(response.Answer, qtype)
+	response.Ns = stripRecords(response.Ns, qtype)
+	response.Extra = stripRecords(response.Extra, qtype)
+
+	if opt == nil {
+		extra := response.Extra[:0]
+		for _, rr := range response.Extra {
+			if rr.Header().Rrtype != dns.TypeOPT {
+				extra = append(extra, rr)
+			}
+		}
+		response.Extra = extra
+	}
+}
+
+func stripRecords(rrs []dns.RR, qtype uint16) (kept []dns.RR) {
+	kept = rrs[:0]
+	for _, rr := range rrs {
+		switch rrtype := rr.Header().Rrtype; rrtype {
+		case dns.TypeRRSIG, dns.TypeNSEC, dns.TypeNSEC3:
+			if rrtype != qtype {
+				continue
+			}
+		}
+		kept = append(kept, rr)
+	}
+	return kept
+}
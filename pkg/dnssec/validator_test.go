@@ -0,0 +1,278 @@
+package dnssec
+
+import (
+	"crypto"
+	"errors"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Unix(1600000000, 0) //nolint:gochecknoglobals
+
+type testZone struct {
+	name   string
+	key    *dns.DNSKEY
+	signer crypto.Signer
+}
+
+func newTestZone(t *testing.T, name string) *testZone {
+	t.Helper()
+	key := &dns.DNSKEY{
+		Hdr: dns.RR_Header{
+			Name: name, Rrtype: dns.TypeDNSKEY, Class: dns.ClassINET, Ttl: 300,
+		},
+		Flags:     dns.ZONE | dns.SEP,
+		Protocol:  3,
+		Algorithm: dns.ECDSAP256SHA256,
+	}
+	privateKey, err := key.Generate(256)
+	require.NoError(t, err)
+	return &testZone{name: name, key: key, signer: privateKey.(crypto.Signer)}
+}
+
+// sign returns the records given followed by their signature,
+// valid for an hour around testNow.
+func (z *testZone) sign(t *testing.T, rrs ...dns.RR) []dns.RR {
+	t.Helper()
+	sig := &dns.RRSIG{
+		Algorithm:  z.key.Algorithm,
+		KeyTag:     z.key.KeyTag(),
+		SignerName: z.name,
+		Inception:  uint32(testNow.Add(-time.Hour).Unix()),
+		Expiration: uint32(testNow.Add(time.Hour).Unix()),
+	}
+	err := sig.Sign(z.signer, rrs)
+	require.NoError(t, err)
+	sig.Hdr.Ttl = rrs[0].Header().Ttl
+	return append(rrs, sig)
+}
+
+func (z *testZone) ds() *dns.DS {
+	return z.key.ToDS(dns.SHA256)
+}
+
+func header(name string, rrtype uint16) dns.RR_Header {
+	return dns.RR_Header{Name: name, Rrtype: rrtype, Class: dns.ClassINET, Ttl: 300}
+}
+
+func newA(name, ip string) *dns.A {
+	return &dns.A{Hdr: header(name, dns.TypeA), A: net.ParseIP(ip)}
+}
+
+func newNSEC(name, next string, types ...uint16) *dns.NSEC {
+	return &dns.NSEC{Hdr: header(name, dns.TypeNSEC), NextDomain: next, TypeBitMap: types}
+}
+
+func newResponse(name string, qtype uint16, rcode int, answer, ns []dns.RR) *dns.Msg {
+	response := new(dns.Msg).SetQuestion(name, qtype)
+	response.Response = true
+	response.Rcode = rcode
+	response.Answer = answer
+	response.Ns = ns
+	return response
+}
+
+// newTestExchange returns an exchange function serving the zones
+// root -> com. -> example.com. and the unsigned zone insecure.com.
+func newTestExchange(t *testing.T, root, com, example *testZone) Exchange {
+	t.Helper()
+
+	responses := []*dns.Msg{
+		newResponse(".", dns.TypeDNSKEY, dns.RcodeSuccess, root.sign(t, root.key), nil),
+		newResponse("com.", dns.TypeDS, dns.RcodeSuccess, root.sign(t, com.ds()), nil),
+		newResponse("com.", dns.TypeDNSKEY, dns.RcodeSuccess, com.sign(t, com.key), nil),
+		newResponse("example.com.", dns.TypeDS, dns.RcodeSuccess, com.sign(t, example.ds()), nil),
+		newResponse("example.com.", dns.TypeDNSKEY, dns.RcodeSuccess, example.sign(t, example.key), nil),
+		newResponse("www.example.com.", dns.TypeDS, dns.RcodeSuccess, nil,
+			example.sign(t, newNSEC("www.example.com.", "z.example.com.",
+				dns.TypeA, dns.TypeRRSIG, dns.TypeNSEC))),
+		newResponse("insecure.com.", dns.TypeDS, dns.RcodeSuccess, nil,
+			com.sign(t, newNSEC("insecure.com.", "z.com.",
+				dns.TypeNS, dns.TypeRRSIG, dns.TypeNSEC))),
+		newResponse("nx.example.com.", dns.TypeDS, dns.RcodeNameError, nil,
+			example.sign(t, newNSEC("example.com.", "www.example.com.",
+				dns.TypeNS, dns.TypeSOA, dns.TypeRRSIG, dns.TypeNSEC, dns.TypeDNSKEY))),
+	}
+
+	keyToResponse := make(map[string]*dns.Msg, len(responses))
+	for _, response := range responses {
+		question := response.Question[0]
+		keyToResponse[question.Name+strconv.Itoa(int(question.Qtype))] = response
+	}
+
+	return func(request *dns.Msg) (*dns.Msg, error) {
+		question := request.Question[0]
+		response, ok := keyToResponse[question.Name+strconv.Itoa(int(question.Qtype))]
+		if !ok {
+			return nil, errors.New("no response for " + question.String())
+		}
+		return response.Copy(), nil
+	}
+}
+
+func Test_Validator_Validate(t *testing.T) {
+	t.Parallel()
+
+	root := newTestZone(t, ".")
+	com := newTestZone(t, "com.")
+	example := newTestZone(t, "example.com.")
+	exchange := newTestExchange(t, root, com, example)
+
+	signedA := example.sign(t, newA("www.example.com.", "1.2.3.4"))
+	tamperedA := example.sign(t, newA("www.example.com.", "1.2.3.4"))
+	tamperedA[0].(*dns.A).A = net.ParseIP("5.6.7.8")
+	nsec := example.sign(t, newNSEC("example.com.", "www.example.com.",
+		dns.TypeNS, dns.TypeSOA, dns.TypeRRSIG, dns.TypeNSEC, dns.TypeDNSKEY))
+
+	testCases := map[string]struct {
+		anchors    []*dns.DS
+		now        time.Time
+		response   *dns.Msg
+		secure     bool
+		errWrapped error
+	}{
+		"secure answer": {
+			response: newResponse("www.example.com.", dns.TypeA, dns.RcodeSuccess, signedA, nil),
+			secure:   true,
+		},
+		"tampered answer": {
+			response:   newResponse("www.example.com.", dns.TypeA, dns.RcodeSuccess, tamperedA, nil),
+			errWrapped: ErrBogus,
+		},
+		"unsigned answer in secure zone": {
+			response: newResponse("www.example.com.", dns.TypeA, dns.RcodeSuccess,
+				[]dns.RR{newA("www.example.com.", "1.2.3.4")}, nil),
+			errWrapped: ErrBogus,
+		},
+		"unsigned answer in insecure zone": {
+			response: newResponse("www.insecure.com.", dns.TypeA, dns.RcodeSuccess,
+				[]dns.RR{newA("www.insecure.com.", "1.2.3.4")}, nil),
+		},
+		"expired signature": {
+			now:        testNow.Add(2 * time.Hour),
+			response:   newResponse("www.example.com.", dns.TypeA, dns.RcodeSuccess, signedA, nil),
+			errWrapped: ErrBogus,
+		},
+		"trust anchor mismatch": {
+			anchors:    []*dns.DS{com.ds()},
+			response:   newResponse("www.example.com.", dns.TypeA, dns.RcodeSuccess, signedA, nil),
+			errWrapped: ErrBogus,
+		},
+		"secure NXDOMAIN": {
+			response: newResponse("nx.example.com.", dns.TypeA, dns.RcodeNameError, nil, nsec),
+			secure:   true,
+		},
+		"secure NODATA": {
+			response: newResponse("www.example.com.", dns.TypeAAAA, dns.RcodeSuccess, nil,
+				example.sign(t, newNSEC("www.example.com.", "z.example.com.",
+					dns.TypeA, dns.TypeRRSIG, dns.TypeNSEC))),
+			secure: true,
+		},
+		"NODATA denying an existing type": {
+			response: newResponse("www.example.com.", dns.TypeA, dns.RcodeSuccess, nil,
+				example.sign(t, newNSEC("www.example.com.", "z.example.com.",
+					dns.TypeA, dns.TypeRRSIG, dns.TypeNSEC))),
+			errWrapped: ErrBogus,
+		},
+		"NXDOMAIN with NSEC not covering the name": {
+			response: newResponse("nx.example.com.", dns.TypeA, dns.RcodeNameError, nil,
+				example.sign(t, newNSEC("www.example.com.", "z.example.com.",
+					dns.TypeA, dns.TypeRRSIG, dns.TypeNSEC))),
+			errWrapped: ErrBogus,
+		},
+		"NXDOMAIN without denial of existence": {
+			response:   newResponse("nx.example.com.", dns.TypeA, dns.RcodeNameError, nil, nil),
+			errWrapped: ErrBogus,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			anchors := testCase.anchors
+			if anchors == nil {
+				anchors = []*dns.DS{root.ds()}
+			}
+			now := testCase.now
+			if now.IsZero() {
+				now = testNow
+			}
+
+			validator := NewValidator(Settings{Enabled: true, TrustAnchors: anchors})
+			validator.timeNow = func() time.Time { return now }
+
+			secure, err := validator.Validate(exchange, testCase.response)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			assert.Equal(t, testCase.secure, secure)
+		})
+	}
+}
+
+func Test_Strip(t *testing.T) {
+	t.Parallel()
+
+	newRequest := func(edns0, do bool) *dns.Msg {
+		request := new(dns.Msg).SetQuestion("example.com.", dns.TypeA)
+		if edns0 {
+			request.SetEdns0(4096, do)
+		}
+		return request
+	}
+	newTestResponse := func() *dns.Msg {
+		response := newResponse("example.com.", dns.TypeA, dns.RcodeSuccess,
+			[]dns.RR{
+				newA("example.com.", "1.2.3.4"),
+				&dns.RRSIG{Hdr: header("example.com.", dns.TypeRRSIG)},
+			},
+			[]dns.RR{newNSEC("example.com.", "a.example.com.")})
+		response.SetEdns0(4096, true)
+		return response
+	}
+
+	testCases := map[string]struct {
+		request *dns.Msg
+		answers int
+		ns      int
+		extra   int
+	}{
+		"DNSSEC OK": {
+			request: newRequest(true, true),
+			answers: 2,
+			ns:      1,
+			extra:   1,
+		},
+		"EDNS0 without DNSSEC OK": {
+			request: newRequest(true, false),
+			answers: 1,
+			extra:   1,
+		},
+		"no EDNS0": {
+			request: newRequest(false, false),
+			answers: 1,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			response := newTestResponse()
+
+			Strip(testCase.request, response)
+
+			assert.Len(t, response.Answer, testCase.answers)
+			assert.Len(t, response.Ns, testCase.ns)
+			assert.Len(t, response.Extra, testCase.extra)
+		})
+	}
+}
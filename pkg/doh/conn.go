@@ -3,15 +3,19 @@ package doh
 import (
 	"bytes"
 	"context"
+	"errors"
 	"net"
 	"net/http"
 	"net/url"
 	"sync"
 	"time"
+
+	"github.com/qdm12/dns/pkg/upstream"
 )
 
 func newDoHConn(ctx context.Context, client *http.Client,
-	bufferPool *sync.Pool, dohURL *url.URL) net.Conn {
+	bufferPool *sync.Pool, dohURL *url.URL, upstreamPicker upstream.Picker,
+	provider string) net.Conn {
 	ctx, cancel := context.WithCancel(ctx)
 	const maxUDPSize = 4096
 	return &dohConn{
@@ -19,6 +23,8 @@ func newDoHConn(ctx context.Context, client *http.Client,
 		client:     client,
 		bufferPool: bufferPool,
 		dohURL:     dohURL,
+		upstream:   upstreamPicker,
+		provider:   provider,
 		inBuffer:   bytes.NewBuffer(make([]byte, 0, maxUDPSize)),
 		outBuffer:  bytes.NewBuffer(make([]byte, 0, maxUDPSize)),
 		cancel:     cancel,
@@ -31,6 +37,8 @@ type dohConn struct {
 	client     *http.Client
 	bufferPool *sync.Pool
 	dohURL     *url.URL
+	upstream   upstream.Picker // to report the exchange result to
+	provider   string          // provider name of the DoH server
 
 	// Internals
 	inBuffer  *bytes.Buffer // TODO obtain from syncPool
@@ -67,11 +75,16 @@ func (c *dohConn) Read(b []byte) (n int, err error) {
 	c.ctx, c.cancel = context.WithCancel(c.ctx)
 	c.ctx, c.cancel = context.WithDeadline(c.ctx, c.deadline)
 
+	requestStart := time.Now()
 	dnsAnswerBytes, err := dohHTTPRequest(c.ctx, c.client, c.bufferPool, c.dohURL, dnsQueryBytes)
 	c.cancel()
 	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			c.upstream.Failure(c.dohURL.String(), err)
+		}
 		return 0, err
 	}
+	c.upstream.Success(c.dohURL.String(), time.Since(requestStart))
 
 	if err := c.writeToOutputBuffer(dnsAnswerBytes); err != nil {
 		return 0, err
@@ -97,10 +110,23 @@ func (c *dohConn) LocalAddr() net.Addr {
 	return nil
 }
 
+// Provider returns the name of the provider of the DoH server.
+func (c *dohConn) Provider() string {
+	return c.provider
+}
+
 func (c *dohConn) RemoteAddr() net.Addr {
-	return nil
+	return &urlAddr{url: c.dohURL}
 }
 
+// urlAddr is the address of a DoH server, identified by its URL.
+type urlAddr struct {
+	url *url.URL
+}
+
+func (a *urlAddr) Network() string { return a.url.Scheme }
+func (a *urlAddr) String() string  { return a.url.String() }
+
 func (c *dohConn) SetDeadline(t time.Time) error {
 	if err := c.SetReadDeadline(t); err != nil {
 		return err
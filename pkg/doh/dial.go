@@ -8,11 +8,20 @@ import (
 
 	"github.com/qdm12/dns/pkg/dot"
 	"github.com/qdm12/dns/pkg/provider"
+	"github.com/qdm12/dns/pkg/upstream"
 )
 
 type dialFunc func(ctx context.Context, _, _ string) (net.Conn, error)
 
-func newDoHDial(settings ResolverSettings) dialFunc {
+// raceDialFunc dials up to n distinct upstream servers at once, and
+// returns the connections dialed successfully.
+type raceDialFunc func(ctx context.Context, n int) (conns []net.Conn, err error)
+
+// newDoHDial returns a function to dial an upstream server, and a
+// function to dial multiple upstream servers for racing queries,
+// sharing the same HTTP client and upstream picker.
+func newDoHDial(settings ResolverSettings) (dial dialFunc, raceDial raceDialFunc,
+	upstreamPicker upstream.Picker) {
 	dohServers := make([]provider.DoHServer, len(settings.DoHProviders))
 	for i := range settings.DoHProviders {
 		dohServers[i] = settings.DoHProviders[i].DoH()
@@ -34,13 +43,30 @@ func newDoHDial(settings ResolverSettings) dialFunc {
 		},
 	}
 
-	picker := newPicker() // fast thread safe random picker
+	picker := upstream.NewPicker(settings.Strategy)
+	dohURLs := make([]string, len(dohServers))
+	for i, dohServer := range dohServers {
+		dohURLs[i] = dohServer.URL.String()
+	}
 
-	return func(ctx context.Context, _, _ string) (conn net.Conn, err error) {
-		// Pick DoH server pseudo-randomly from the chosen providers
-		DoHServer := picker.DoHServer(dohServers)
+	dial = func(ctx context.Context, _, _ string) (conn net.Conn, err error) {
+		// Pick DoH server from the chosen providers
+		index := picker.Pick(dohURLs)
 		// Create connection object (no actual IO yet)
-		conn = newDoHConn(ctx, dotClient, bufferPool, DoHServer.URL)
+		conn = newDoHConn(ctx, dotClient, bufferPool, dohServers[index].URL, picker,
+			settings.DoHProviders[index].String())
 		return conn, nil
 	}
+
+	raceDial = func(ctx context.Context, n int) (conns []net.Conn, err error) {
+		indexes := picker.PickN(dohURLs, n)
+		conns = make([]net.Conn, len(indexes))
+		for i, index := range indexes {
+			conns[i] = newDoHConn(ctx, dotClient, bufferPool, dohServers[index].URL, picker,
+				settings.DoHProviders[index].String())
+		}
+		return conns, nil
+	}
+
+	return dial, raceDial, picker
 }
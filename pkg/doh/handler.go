@@ -2,10 +2,16 @@ package doh
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
 
 	"github.com/miekg/dns"
 	"github.com/qdm12/dns/pkg/blacklist"
-	"github.com/qdm12/dns/pkg/cache"
+	"github.com/qdm12/dns/pkg/dnssec"
+	"github.com/qdm12/dns/pkg/metrics"
+	"github.com/qdm12/dns/pkg/querylog"
 	"github.com/qdm12/golibs/logging"
 )
 
@@ -15,77 +21,314 @@ type handler struct {
 	logger logging.Logger
 
 	// Internal objects
-	dial   dialFunc
-	client *dns.Client
-	cache  cache.Cache
-	blist  blacklist.BlackLister
+	client            *dns.Client
+	defaultPolicy     *policy
+	policies          []*policy // client policies, matched in order
+	blockResponse     blacklist.ResponseSettings
+	cacheSnapshotPath string            // empty if disabled
+	queryLogger       querylog.Logger   // nil if disabled
+	queryRing         *querylog.Ring    // nil if disabled
+	validator         *dnssec.Validator // nil if disabled
+	metrics           metrics.Interface
 }
 
 func newDNSHandler(ctx context.Context, logger logging.Logger,
-	settings ServerSettings) dns.Handler {
+	settings ServerSettings) *handler {
+	policies := make([]*policy, len(settings.Policies))
+	for i, policySettings := range settings.Policies {
+		resolverSettings := settings.Resolver
+		resolverSettings.DoHProviders = policySettings.DoHProviders
+		policies[i] = newPolicy(policySettings.Name, policySettings.Subnets,
+			resolverSettings, settings.Cache, policySettings.Blacklist)
+	}
+
+	queryLogger, queryRing := querylog.New(settings.QueryLog)
+
+	var validator *dnssec.Validator
+	if settings.DNSSEC.Enabled {
+		validator = dnssec.NewValidator(settings.DNSSEC)
+	}
+
 	return &handler{
 		ctx:    ctx,
 		logger: logger,
-		dial:   newDoHDial(settings.Resolver),
 		client: &dns.Client{},
-		cache:  cache.New(settings.Cache),
-		blist:  blacklist.NewMap(settings.Blacklist),
+		defaultPolicy: newPolicy("default", nil, settings.Resolver,
+			settings.Cache, settings.Blacklist),
+		policies:          policies,
+		blockResponse:     settings.BlockResponse,
+		cacheSnapshotPath: settings.Cache.SnapshotPath,
+		queryLogger:       queryLogger,
+		queryRing:         queryRing,
+		validator:         validator,
+		metrics:           settings.Metrics,
 	}
 }
 
+// UpdateBlacklist atomically replaces the blacklist used to filter
+// queries and responses of clients not matching any policy. Queries
+// being handled finish using the blacklist they started with, so no
+// query is dropped.
+func (h *handler) UpdateBlacklist(settings blacklist.Settings) {
+	h.defaultPolicy.updateBlacklist(settings)
+}
+
 func (h *handler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
-	if h.cache != nil {
-		if response := h.cache.Get(r); response != nil {
-			response.SetReply(r)
-			if err := w.WriteMsg(response); err != nil {
-				h.logger.Warn("cannot write DNS message back to client: " + err.Error())
+	entry := querylog.Entry{Time: time.Now()}
+	entry.SetQuery(w.RemoteAddr(), r)
+
+	policy := h.policyFor(w.RemoteAddr())
+	response := h.resolve(policy, r, &entry)
+
+	if err := w.WriteMsg(response); err != nil {
+		h.logger.Warn("cannot write DNS message back to client: " + err.Error())
+	}
+
+	entry.SetResponse(response)
+	h.metrics.Query(entry.Type, entry.Rcode)
+
+	if h.queryLogger != nil {
+		if err := h.queryLogger.Log(entry); err != nil {
+			h.logger.Warn("cannot log query: " + err.Error())
+		}
+	}
+}
+
+// resolve returns the response to the request using the
+// policy given, and sets the query log entry fields.
+func (h *handler) resolve(policy *policy, r *dns.Msg,
+	entry *querylog.Entry) (response *dns.Msg) {
+	blist := policy.loadBlacklist()
+
+	// The blacklist is checked before the cache, for blacklist
+	// updates to apply to cached responses as well.
+	if blist.FilterRequest(r) {
+		entry.Blocked = querylog.BlockedHostname
+		h.metrics.Blocked(entry.Blocked)
+		return h.blockResponse.BlockedResponse(r)
+	}
+
+	if policy.cache != nil {
+		if response, prefetch := policy.cache.Get(r); response != nil {
+			if blist.FilterResponse(response) {
+				entry.Blocked = querylog.BlockedIP
+				h.metrics.Blocked(entry.Blocked)
+				return h.blockResponse.BlockedResponse(r)
+			}
+			entry.CacheHit = true
+			if prefetch {
+				go h.prefetch(policy, r.Copy())
 			}
-			return
+			setReply(response, r)
+			return response
 		}
 	}
 
-	if h.blist.FilterRequest(r) {
-		response := new(dns.Msg).SetRcode(r, dns.RcodeRefused)
-		if err := w.WriteMsg(response); err != nil {
-			h.logger.Warn("cannot write DNS message back to client: " + err.Error())
+	response, err := h.query(policy, r, entry)
+	if err != nil {
+		h.logger.Warn(err.Error())
+	}
+
+	if err != nil || response.Rcode == dns.RcodeServerFailure {
+		// Bogus responses are not replaced by stale responses,
+		// since the upstream server may be spoofed.
+		if policy.cache != nil && !errors.Is(err, dnssec.ErrBogus) {
+			if stale := policy.cache.GetStale(r); stale != nil {
+				entry.CacheHit = true
+				setReply(stale, r)
+				return stale
+			}
 		}
-		return
+
+		if err != nil {
+			return new(dns.Msg).SetRcode(r, dns.RcodeServerFailure)
+		}
+	}
+
+	if blist.FilterResponse(response) {
+		entry.Blocked = querylog.BlockedIP
+		h.metrics.Blocked(entry.Blocked)
+		return h.blockResponse.BlockedResponse(r)
+	}
+
+	if policy.cache != nil {
+		policy.cache.Add(r, response)
+	}
+
+	setReply(response, r)
+	return response
+}
+
+// setReply sets the response as reply to the request like SetReply,
+// but keeps the response code of the response instead of resetting
+// it to NOERROR, for negative and SERVFAIL responses.
+func setReply(response, request *dns.Msg) {
+	rcode := response.Rcode
+	response.SetReply(request)
+	response.Rcode = rcode
+}
+
+// query sends the request to an upstream server of the policy
+// given and returns its response. If DNSSEC validation is enabled,
+// the request is sent with the DNSSEC OK bit set and the response
+// is validated, unless the client disabled checking. An error is
+// returned if the validation fails, wrapping dnssec.ErrBogus if the
+// response is bogus, and the DNSSEC records the client did not ask
+// for are removed otherwise.
+func (h *handler) query(policy *policy, r *dns.Msg,
+	entry *querylog.Entry) (response *dns.Msg, err error) {
+	if h.validator == nil {
+		return h.exchange(policy, r, entry)
 	}
 
-	DoHConn, err := h.dial(h.ctx, "", "")
+	response, err = h.exchange(policy, dnssec.WithDO(r), entry)
+	if err != nil || response.Rcode == dns.RcodeServerFailure {
+		return response, err
+	}
+
+	if !r.CheckingDisabled {
+		exchange := func(request *dns.Msg) (*dns.Msg, error) {
+			var entry querylog.Entry
+			return h.exchange(policy, request, &entry)
+		}
+		secure, err := h.validator.Validate(exchange, response)
+		if err != nil {
+			return nil, err
+		}
+		response.AuthenticatedData = secure
+	}
+
+	dnssec.Strip(r, response)
+	return response, nil
+}
+
+// exchange sends the request to an upstream server of the policy
+// given and returns its response, within the policy timeout.
+// If racing is enabled, the request is sent to multiple upstream
+// servers at once instead. It sets the upstream field of the query
+// log entry.
+func (h *handler) exchange(policy *policy, r *dns.Msg,
+	entry *querylog.Entry) (response *dns.Msg, err error) {
+	ctx, cancel := context.WithTimeout(h.ctx, policy.timeout)
+	defer cancel() // also cancels the exchanges losing the race
+
+	if policy.race > 1 {
+		return h.race(ctx, policy, r, entry)
+	}
+
+	DoHConn, err := policy.dial(ctx, "", "")
 	if err != nil {
-		h.logger.Warn("cannot dial: " + err.Error())
-		_ = w.WriteMsg(new(dns.Msg).SetRcode(r, dns.RcodeServerFailure))
-		return
+		h.metrics.DialError()
+		return nil, fmt.Errorf("cannot dial: %w", err)
+	}
+
+	response, entry.Upstream, err = h.exchangeWithConn(ctx, DoHConn, r)
+	return response, err
+}
+
+// race sends the request to multiple upstream servers of the policy
+// at once, and returns the first response which is not SERVFAIL.
+// If there is no such response, it returns a SERVFAIL response if
+// any, or the last error encountered. The caller should cancel the
+// context once it returns, to cancel the exchanges still running.
+func (h *handler) race(ctx context.Context, policy *policy, r *dns.Msg,
+	entry *querylog.Entry) (response *dns.Msg, err error) {
+	conns, err := policy.raceDial(ctx, policy.race)
+	if err != nil {
+		h.metrics.DialError()
+		return nil, fmt.Errorf("cannot dial: %w", err)
+	}
+
+	type result struct {
+		response *dns.Msg
+		upstream string
+		err      error
+	}
+	results := make(chan result, len(conns))
+	for _, DoHConn := range conns {
+		go func(DoHConn net.Conn, r *dns.Msg) {
+			var res result
+			res.response, res.upstream, res.err = h.exchangeWithConn(ctx, DoHConn, r)
+			results <- res
+		}(DoHConn, r.Copy())
+	}
+
+	for range conns {
+		res := <-results
+		switch {
+		case res.err != nil:
+			err = res.err
+		case res.response.Rcode == dns.RcodeServerFailure:
+			response, entry.Upstream = res.response, res.upstream
+		default:
+			entry.Upstream = res.upstream
+			return res.response, nil
+		}
+	}
+
+	if response != nil {
+		return response, nil
+	}
+	return nil, err
+}
+
+// exchangeWithConn sends the request over the upstream connection
+// given and returns its response, and closes the connection.
+// The exchange duration is recorded by provider, unless the
+// context is canceled, for example for exchanges losing a race.
+func (h *handler) exchangeWithConn(ctx context.Context, DoHConn net.Conn, r *dns.Msg) (
+	response *dns.Msg, upstream string, err error) {
+	if addr := DoHConn.RemoteAddr(); addr != nil {
+		upstream = addr.String()
 	}
 	conn := &dns.Conn{Conn: DoHConn}
 
-	response, _, err := h.client.ExchangeWithConn(r, conn)
+	exchangeStart := time.Now()
+	response, _, err = h.client.ExchangeWithConn(r, conn)
+	if !errors.Is(ctx.Err(), context.Canceled) {
+		h.metrics.UpstreamLatency(providerName(DoHConn), time.Since(exchangeStart))
+	}
 
 	if err := conn.Close(); err != nil {
-		h.logger.Warn("cannot close the DoT connection: " + err.Error())
+		h.logger.Warn("cannot close the DoH connection: " + err.Error())
 	}
 
 	if err != nil {
-		h.logger.Warn("cannot exchange over DoH connection: " + err.Error())
-		_ = w.WriteMsg(new(dns.Msg).SetRcode(r, dns.RcodeServerFailure))
-		return
+		return nil, upstream, fmt.Errorf("cannot exchange over DoH connection: %w", err)
 	}
+	return response, upstream, nil
+}
 
-	if h.blist.FilterResponse(response) {
-		response := new(dns.Msg).SetRcode(r, dns.RcodeRefused)
-		if err := w.WriteMsg(response); err != nil {
-			h.logger.Warn("cannot write DNS message back to client: " + err.Error())
-		}
+// prefetch refreshes the cached response to the request from
+// upstream, and is meant to be run in its own goroutine.
+func (h *handler) prefetch(policy *policy, r *dns.Msg) {
+	var entry querylog.Entry
+	response, err := h.query(policy, r, &entry)
+	if err != nil {
+		h.logger.Warn("cannot prefetch: " + err.Error())
 		return
 	}
 
-	if h.cache != nil {
-		h.cache.Add(r, response)
+	if response.Rcode == dns.RcodeServerFailure ||
+		policy.loadBlacklist().FilterResponse(response) {
+		// keep the current cached response until it expires
+		return
 	}
 
-	response.SetReply(r)
-	if err := w.WriteMsg(response); err != nil {
-		h.logger.Warn("cannot write DNS message back to client: " + err.Error())
+	policy.cache.Add(r, response)
+}
+
+// providerConn is implemented by upstream connections
+// knowing the name of the provider of their upstream server.
+type providerConn interface {
+	Provider() string
+}
+
+// providerName returns the provider name of the upstream
+// connection given, or "unknown" if it is not known.
+func providerName(conn net.Conn) string {
+	if conn, ok := conn.(providerConn); ok {
+		return conn.Provider()
 	}
+	return "unknown"
 }
@@ -0,0 +1,55 @@
+package doh
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/querylog"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_handler_exchangeTimeout(t *testing.T) {
+	t.Parallel()
+
+	settings := ServerSettings{}
+	settings.Resolver.Timeout = 50 * time.Millisecond
+	settings.SetDefaults()
+	handler := newDNSHandler(context.Background(), nil, settings)
+
+	var dialCtx context.Context
+	handler.defaultPolicy.dial = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		dialCtx = ctx
+		conn, _ := net.Pipe() // never answers
+		return &contextConn{Conn: conn, ctx: ctx}, nil
+	}
+
+	request := new(dns.Msg).SetQuestion("github.com.", dns.TypeA)
+	var entry querylog.Entry
+	start := time.Now()
+	response, err := handler.exchange(handler.defaultPolicy, request, &entry)
+
+	assert.Error(t, err)
+	assert.Nil(t, response)
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+	assert.ErrorIs(t, dialCtx.Err(), context.DeadlineExceeded)
+}
+
+// contextConn is a connection accepting all writes and whose reads
+// block until its context is done, like a DNS over HTTPS connection
+// to a stalled upstream server.
+type contextConn struct {
+	net.Conn
+	ctx context.Context
+}
+
+func (c *contextConn) Write(b []byte) (n int, err error) {
+	return len(b), nil
+}
+
+func (c *contextConn) Read(b []byte) (n int, err error) {
+	<-c.ctx.Done()
+	return 0, c.ctx.Err()
+}
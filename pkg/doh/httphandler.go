@@ -0,0 +1,147 @@
+package doh
+
+import (
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/golibs/logging"
+)
+
+const dnsMessageContentType = "application/dns-message"
+
+// newHTTPHandler creates an HTTP handler implementing the
+// DNS over HTTPS server side of RFC 8484, running each DNS query
+// received through the DNS handler given.
+func newHTTPHandler(logger logging.Logger, path string,
+	dnsHandler dns.Handler) http.Handler {
+	return &httpHandler{
+		logger:     logger,
+		path:       path,
+		dnsHandler: dnsHandler,
+	}
+}
+
+type httpHandler struct {
+	logger     logging.Logger
+	path       string
+	dnsHandler dns.Handler
+}
+
+func (h *httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != h.path {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	var wire []byte
+	switch r.Method {
+	case http.MethodGet:
+		encoded := r.URL.Query().Get("dns")
+		if encoded == "" {
+			http.Error(w, "missing dns query parameter", http.StatusBadRequest)
+			return
+		}
+		var err error
+		wire, err = base64.RawURLEncoding.DecodeString(encoded)
+		if err != nil {
+			http.Error(w, "cannot decode dns query parameter: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	case http.MethodPost:
+		if r.Header.Get("Content-Type") != dnsMessageContentType {
+			http.Error(w, http.StatusText(http.StatusUnsupportedMediaType),
+				http.StatusUnsupportedMediaType)
+			return
+		}
+		const maxMessageSize = 65535
+		var err error
+		wire, err = io.ReadAll(io.LimitReader(r.Body, maxMessageSize+1))
+		if err != nil {
+			http.Error(w, "cannot read body: "+err.Error(), http.StatusBadRequest)
+			return
+		} else if len(wire) > maxMessageSize {
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge),
+				http.StatusRequestEntityTooLarge)
+			return
+		}
+	default:
+		w.Header().Set("Allow", http.MethodGet+", "+http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	request := new(dns.Msg)
+	if err := request.Unpack(wire); err != nil {
+		http.Error(w, "cannot decode DNS message: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	localAddr, _ := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	responseWriter := &responseWriter{
+		localAddr:  localAddr,
+		remoteAddr: parseTCPAddr(r.RemoteAddr),
+	}
+	h.dnsHandler.ServeDNS(responseWriter, request)
+	if responseWriter.response == nil {
+		http.Error(w, "no DNS response", http.StatusInternalServerError)
+		return
+	}
+
+	responseWire, err := responseWriter.response.Pack()
+	if err != nil {
+		http.Error(w, "cannot encode DNS message: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", dnsMessageContentType)
+	if maxAge, ok := getMaxAge(responseWriter.response); ok {
+		w.Header().Set("Cache-Control", "max-age="+strconv.Itoa(int(maxAge)))
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(responseWire)))
+	if _, err := w.Write(responseWire); err != nil {
+		h.logger.Warn("cannot write DNS message back to HTTP client: " + err.Error())
+	}
+}
+
+// getMaxAge returns the smallest TTL of the response records,
+// ignoring the OPT pseudo record. It returns ok as false if the
+// response contains no record, in which case the response should
+// not be cached by HTTP caches.
+func getMaxAge(response *dns.Msg) (maxAge uint32, ok bool) {
+	maxAge = ^uint32(0)
+	for _, section := range [][]dns.RR{response.Answer, response.Ns, response.Extra} {
+		for _, rr := range section {
+			header := rr.Header()
+			if header.Rrtype == dns.TypeOPT {
+				continue
+			}
+			ok = true
+			if header.Ttl < maxAge {
+				maxAge = header.Ttl
+			}
+		}
+	}
+
+	if !ok {
+		return 0, false
+	}
+	return maxAge, true
+}
+
+// parseTCPAddr parses an address of the form host:port
+// without doing any DNS resolution.
+func parseTCPAddr(address string) (addr *net.TCPAddr) {
+	host, portString, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+	port, _ := strconv.Atoi(portString)
+	return &net.TCPAddr{
+		IP:   net.ParseIP(host),
+		Port: port,
+	}
+}
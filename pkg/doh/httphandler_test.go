@@ -0,0 +1,130 @@
+package doh
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDNSHandler() dns.Handler {
+	return dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		response := new(dns.Msg).SetReply(r)
+		response.Answer = []dns.RR{
+			&dns.A{
+				Hdr: dns.RR_Header{Name: r.Question[0].Name, Rrtype: dns.TypeA,
+					Class: dns.ClassINET, Ttl: 300},
+				A: net.IP{1, 2, 3, 4},
+			},
+			&dns.A{
+				Hdr: dns.RR_Header{Name: r.Question[0].Name, Rrtype: dns.TypeA,
+					Class: dns.ClassINET, Ttl: 60},
+				A: net.IP{5, 6, 7, 8},
+			},
+		}
+		_ = w.WriteMsg(response)
+	})
+}
+
+func Test_httpHandler(t *testing.T) {
+	t.Parallel()
+
+	query := new(dns.Msg).SetQuestion("github.com.", dns.TypeA)
+	query.Id = 0
+	wire, err := query.Pack()
+	require.NoError(t, err)
+
+	testCases := map[string]struct {
+		request      *http.Request
+		status       int
+		cacheControl string
+	}{
+		"GET": {
+			request: httptest.NewRequest(http.MethodGet,
+				"/dns-query?dns="+base64.RawURLEncoding.EncodeToString(wire), nil),
+			status:       http.StatusOK,
+			cacheControl: "max-age=60",
+		},
+		"POST": {
+			request: func() *http.Request {
+				request := httptest.NewRequest(http.MethodPost,
+					"/dns-query", bytes.NewReader(wire))
+				request.Header.Set("Content-Type", "application/dns-message")
+				return request
+			}(),
+			status:       http.StatusOK,
+			cacheControl: "max-age=60",
+		},
+		"bad path": {
+			request: httptest.NewRequest(http.MethodGet, "/other", nil),
+			status:  http.StatusNotFound,
+		},
+		"GET without dns parameter": {
+			request: httptest.NewRequest(http.MethodGet, "/dns-query", nil),
+			status:  http.StatusBadRequest,
+		},
+		"GET with bad base64": {
+			request: httptest.NewRequest(http.MethodGet, "/dns-query?dns=%%%", nil),
+			status:  http.StatusBadRequest,
+		},
+		"POST with bad content type": {
+			request: httptest.NewRequest(http.MethodPost,
+				"/dns-query", bytes.NewReader(wire)),
+			status: http.StatusUnsupportedMediaType,
+		},
+		"PUT": {
+			request: httptest.NewRequest(http.MethodPut, "/dns-query", nil),
+			status:  http.StatusMethodNotAllowed,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := newHTTPHandler(nil, "/dns-query", newTestDNSHandler())
+			recorder := httptest.NewRecorder()
+
+			handler.ServeHTTP(recorder, testCase.request)
+
+			result := recorder.Result()
+			defer result.Body.Close()
+			assert.Equal(t, testCase.status, result.StatusCode)
+			if testCase.status != http.StatusOK {
+				return
+			}
+
+			assert.Equal(t, "application/dns-message", result.Header.Get("Content-Type"))
+			assert.Equal(t, testCase.cacheControl, result.Header.Get("Cache-Control"))
+			response := new(dns.Msg)
+			require.NoError(t, response.Unpack(recorder.Body.Bytes()))
+			assert.Equal(t, query.Id, response.Id)
+			assert.Len(t, response.Answer, 2)
+		})
+	}
+}
+
+func Test_getMaxAge(t *testing.T) {
+	t.Parallel()
+
+	maxAge, ok := getMaxAge(&dns.Msg{})
+	assert.False(t, ok)
+	assert.Zero(t, maxAge)
+
+	response := &dns.Msg{
+		Ns: []dns.RR{&dns.SOA{Hdr: dns.RR_Header{Rrtype: dns.TypeSOA, Ttl: 900}}},
+		Extra: []dns.RR{&dns.OPT{
+			Hdr: dns.RR_Header{Rrtype: dns.TypeOPT},
+		}},
+	}
+	maxAge, ok = getMaxAge(response)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(900), maxAge)
+}
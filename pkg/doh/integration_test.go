@@ -37,7 +37,8 @@ func Test_Server(t *testing.T) {
 	stopped := make(chan error)
 
 	logger := mock_logging.NewMockLogger(ctrl)
-	logger.EXPECT().Info("DNS server listening on :53")
+	logger.EXPECT().Info("DNS server listening on :53 (udp)")
+	logger.EXPECT().Info("DNS server listening on :53 (tcp)")
 
 	server := NewServer(ctx, logger, ServerSettings{})
 
@@ -6,10 +6,11 @@ import (
 
 // NewResolver creates a DNS over HTTPs resolver.
 func NewResolver(settings ResolverSettings) *net.Resolver {
-	settings.setDefaults()
+	settings.SetDefaults()
+	dial, _, _ := newDoHDial(settings)
 	return &net.Resolver{
 		PreferGo:     true,
 		StrictErrors: true,
-		Dial:         newDoHDial(settings),
+		Dial:         dial,
 	}
 }
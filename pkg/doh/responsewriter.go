@@ -0,0 +1,38 @@
+package doh
+
+import (
+	"net"
+
+	"github.com/miekg/dns"
+)
+
+// responseWriter is a dns.ResponseWriter implementation used to
+// run the DNS handler for a DNS query received over HTTP, keeping
+// the DNS response message in memory to write it back over HTTP.
+type responseWriter struct {
+	localAddr  net.Addr
+	remoteAddr net.Addr
+	response   *dns.Msg
+}
+
+func (w *responseWriter) LocalAddr() net.Addr  { return w.localAddr }
+func (w *responseWriter) RemoteAddr() net.Addr { return w.remoteAddr }
+
+func (w *responseWriter) WriteMsg(response *dns.Msg) error {
+	w.response = response
+	return nil
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	response := new(dns.Msg)
+	if err := response.Unpack(b); err != nil {
+		return 0, err
+	}
+	w.response = response
+	return len(b), nil
+}
+
+func (w *responseWriter) Close() error        { return nil }
+func (w *responseWriter) TsigStatus() error   { return nil }
+func (w *responseWriter) TsigTimersOnly(bool) {}
+func (w *responseWriter) Hijack()             {}
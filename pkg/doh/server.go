@@ -2,11 +2,18 @@ package doh
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
 	"runtime"
 	"strconv"
 	"time"
 
 	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/blacklist"
+	"github.com/qdm12/dns/pkg/cache"
+	"github.com/qdm12/dns/pkg/querylog"
+	"github.com/qdm12/dns/pkg/upstream"
 	"github.com/qdm12/golibs/logging"
 )
 
@@ -14,11 +21,19 @@ import (
 
 type Server interface {
 	Run(ctx context.Context, stopped chan<- error)
+	UpdateBlacklist(settings blacklist.Settings)
+	UpdatePolicyBlacklist(name string, settings blacklist.Settings) error
+	QueryLog() (entries []querylog.Entry)
+	Caches() (policyToCache map[string]cache.Cache)
+	FailingUpstreams() (policyToUpstreams map[string][]upstream.Health)
 }
 
 type server struct {
-	dnsServer dns.Server
-	logger    logging.Logger
+	handler      *handler
+	dnsServers   []*dns.Server
+	httpServer   *http.Server // nil if disabled
+	httpSettings HTTPSettings
+	logger       logging.Logger
 }
 
 func NewServer(ctx context.Context, logger logging.Logger,
@@ -27,30 +42,208 @@ func NewServer(ctx context.Context, logger logging.Logger,
 		logger.Warn("The Windows host cannot use the DoH server as its DNS")
 	}
 
-	settings.setDefaults()
+	settings.SetDefaults()
+
+	// The handler is shared by all the listeners
+	// so they share the same cache and blacklist.
+	handler := newDNSHandler(ctx, logger, settings)
+	address := ":" + strconv.Itoa(int(settings.Port))
+
+	var httpServer *http.Server
+	if settings.HTTP.Enabled {
+		// Timeouts prevent slow clients from holding
+		// connections open for ever.
+		const (
+			readHeaderTimeout = 5 * time.Second
+			readTimeout       = 10 * time.Second
+			idleTimeout       = 2 * time.Minute
+		)
+		httpServer = &http.Server{
+			Addr:              ":" + strconv.Itoa(int(settings.HTTP.Port)),
+			Handler:           newHTTPHandler(logger, settings.HTTP.Path, handler),
+			ReadHeaderTimeout: readHeaderTimeout,
+			ReadTimeout:       readTimeout,
+			IdleTimeout:       idleTimeout,
+		}
+	}
 
 	return &server{
-		dnsServer: dns.Server{
-			Addr:    ":" + strconv.Itoa(int(settings.Port)),
-			Net:     "udp",
-			Handler: newDNSHandler(ctx, logger, settings),
+		handler: handler,
+		dnsServers: []*dns.Server{
+			{Addr: address, Net: "udp", Handler: handler},
+			{Addr: address, Net: "tcp", Handler: handler},
 		},
-		logger: logger,
+		httpServer:   httpServer,
+		httpSettings: settings.HTTP,
+		logger:       logger,
+	}
+}
+
+// UpdateBlacklist updates the blacklist used by all the listeners
+// without restarting them.
+func (s *server) UpdateBlacklist(settings blacklist.Settings) {
+	s.handler.UpdateBlacklist(settings)
+}
+
+// UpdatePolicyBlacklist updates the blacklist used for the clients
+// of the policy with the name given, without restarting the listeners.
+func (s *server) UpdatePolicyBlacklist(name string, settings blacklist.Settings) error {
+	return s.handler.UpdatePolicyBlacklist(name, settings)
+}
+
+// QueryLog returns the most recent queries kept in memory,
+// from the oldest to the most recent one. It returns nil
+// if keeping queries in memory is disabled.
+func (s *server) QueryLog() (entries []querylog.Entry) {
+	if s.handler.queryRing == nil {
+		return nil
+	}
+	return s.handler.queryRing.Entries()
+}
+
+// Caches returns the cache of each policy by policy name, where the
+// policy for clients not matching any client policy is named default.
+// It returns an empty map if caching is disabled.
+func (s *server) Caches() (policyToCache map[string]cache.Cache) {
+	policies := s.handler.cachedPolicies()
+	policyToCache = make(map[string]cache.Cache, len(policies))
+	for _, p := range policies {
+		policyToCache[p.name] = p.cache
+	}
+	return policyToCache
+}
+
+// FailingUpstreams returns the health of the upstream servers which
+// failed since their last success by policy name, where the policy
+// for clients not matching any client policy is named default.
+func (s *server) FailingUpstreams() (policyToUpstreams map[string][]upstream.Health) {
+	policies := append([]*policy{s.handler.defaultPolicy}, s.handler.policies...)
+	policyToUpstreams = make(map[string][]upstream.Health, len(policies))
+	for _, p := range policies {
+		policyToUpstreams[p.name] = p.upstream.Failing()
 	}
+	return policyToUpstreams
+}
+
+type serverEvent struct {
+	dnsServer *dns.Server
+	started   bool
+	err       error
 }
 
+// Run runs all the listeners and blocks until the context is canceled
+// or one of the listeners stops unexpectedly. In both cases, all the
+// listeners still running are shut down and the first error encountered
+// is sent to the stopped channel. If cache persistence is enabled, the
+// caches are loaded before the listeners start, and are saved once all
+// the listeners exited.
 func (s *server) Run(ctx context.Context, stopped chan<- error) {
-	go func() { // shutdown goroutine
-		<-ctx.Done()
+	s.handler.loadCaches()
 
-		const graceTime = 100 * time.Millisecond
-		ctx, cancel := context.WithTimeout(context.Background(), graceTime)
-		defer cancel()
-		if err := s.dnsServer.ShutdownContext(ctx); err != nil {
-			s.logger.Error("DNS server shutdown error: " + err.Error())
+	events := make(chan serverEvent)
+	for _, dnsServer := range s.dnsServers {
+		dnsServer := dnsServer
+		dnsServer.NotifyStartedFunc = func() {
+			events <- serverEvent{dnsServer: dnsServer, started: true}
 		}
-	}()
+		s.logger.Info("DNS server listening on " + dnsServer.Addr + " (" + dnsServer.Net + ")")
+		go func() {
+			err := dnsServer.ListenAndServe()
+			if err != nil {
+				err = fmt.Errorf("%s server: %w", dnsServer.Net, err)
+			}
+			events <- serverEvent{dnsServer: dnsServer, err: err}
+		}()
+	}
+
+	httpStopped := make(chan error, 1)
+	if s.httpServer != nil {
+		s.logger.Info("DNS over HTTPS server listening on " +
+			s.httpServer.Addr + s.httpSettings.Path)
+		go func() {
+			httpStopped <- s.runHTTP()
+		}()
+	}
 
-	s.logger.Info("DNS server listening on " + s.dnsServer.Addr)
-	stopped <- s.dnsServer.ListenAndServe()
+	// Wait for each listener to either start or fail to start.
+	// A listener can also start and exit right away, sending
+	// both events, so the state of each listener is tracked.
+	starting := make(map[*dns.Server]struct{}, len(s.dnsServers))
+	for _, dnsServer := range s.dnsServers {
+		starting[dnsServer] = struct{}{}
+	}
+	running := make(map[*dns.Server]struct{}, len(s.dnsServers))
+	var err error
+	var failed bool
+	for len(starting) > 0 {
+		event := <-events
+		delete(starting, event.dnsServer)
+		if event.started {
+			running[event.dnsServer] = struct{}{}
+			continue
+		}
+		delete(running, event.dnsServer)
+		failed = true
+		if err == nil {
+			err = event.err
+		}
+	}
+
+	httpRunning := s.httpServer != nil
+	if !failed {
+		select {
+		case <-ctx.Done():
+		case event := <-events: // listener stopped unexpectedly
+			delete(running, event.dnsServer)
+			err = event.err
+		case err = <-httpStopped: // only receives if the HTTP server is enabled
+			httpRunning = false
+		}
+	}
+
+	const graceTime = 100 * time.Millisecond
+
+	for dnsServer := range running {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), graceTime)
+		if shutdownErr := dnsServer.ShutdownContext(shutdownCtx); shutdownErr != nil {
+			s.logger.Error("DNS " + dnsServer.Net + " server shutdown error: " + shutdownErr.Error())
+		}
+		cancel()
+	}
+
+	if httpRunning {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), graceTime)
+		if shutdownErr := s.httpServer.Shutdown(shutdownCtx); shutdownErr != nil {
+			s.logger.Error("DNS over HTTPS server shutdown error: " + shutdownErr.Error())
+		}
+		cancel()
+		if httpErr := <-httpStopped; err == nil {
+			err = httpErr
+		}
+	}
+
+	for range running { // wait for listeners to exit
+		event := <-events
+		if err == nil {
+			err = event.err
+		}
+	}
+
+	s.handler.saveCaches()
+
+	stopped <- err
+}
+
+func (s *server) runHTTP() (err error) {
+	if s.httpSettings.CertificateFile == "" && s.httpSettings.KeyFile == "" {
+		err = s.httpServer.ListenAndServe()
+	} else {
+		err = s.httpServer.ListenAndServeTLS(
+			s.httpSettings.CertificateFile, s.httpSettings.KeyFile)
+	}
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return fmt.Errorf("http server: %w", err)
 }
@@ -7,20 +7,74 @@ import (
 
 	"github.com/qdm12/dns/pkg/blacklist"
 	"github.com/qdm12/dns/pkg/cache"
+	"github.com/qdm12/dns/pkg/dnssec"
+	"github.com/qdm12/dns/pkg/metrics"
 	"github.com/qdm12/dns/pkg/provider"
+	"github.com/qdm12/dns/pkg/querylog"
+	"github.com/qdm12/dns/pkg/upstream"
+	"inet.af/netaddr"
 )
 
 type ServerSettings struct {
 	Resolver  ResolverSettings
 	Port      uint16
+	HTTP      HTTPSettings
 	Cache     cache.Settings
 	Blacklist blacklist.Settings
+	// BlockResponse are the settings for the response
+	// sent back to the client for a blocked query.
+	BlockResponse blacklist.ResponseSettings
+	// Policies are filtering policies for groups of clients,
+	// matched in order using the client IP address. Clients
+	// matching no policy use the settings above.
+	Policies []PolicySettings
+	// QueryLog are the settings to log the DNS queries handled.
+	QueryLog querylog.Settings
+	// DNSSEC are the settings to validate upstream responses
+	// with DNSSEC, and validation defaults to disabled.
+	DNSSEC dnssec.Settings
+	// Metrics is the interface to record the server metrics,
+	// and defaults to a no-op implementation.
+	Metrics metrics.Interface
+}
+
+// PolicySettings are settings for a filtering policy applied
+// to clients with an IP address in one of the policy subnets.
+type PolicySettings struct {
+	Name    string
+	Subnets []netaddr.IPPrefix
+	// DoHProviders are the DNS over HTTPS providers to use for the
+	// policy clients, and default to the resolver DoH providers.
+	DoHProviders []provider.Provider
+	Blacklist    blacklist.Settings
+}
+
+// HTTPSettings are settings to serve DNS over HTTPS to clients
+// as described in RFC 8484, in addition to plaintext DNS over UDP and TCP.
+type HTTPSettings struct {
+	Enabled bool
+	Port    uint16
+	// Path is the URL path to serve DNS queries on.
+	Path string
+	// CertificateFile is the path to the PEM encoded certificate file.
+	// If it and KeyFile are left empty, the server listens over plain HTTP,
+	// for example to be used behind a TLS terminating reverse proxy.
+	CertificateFile string
+	// KeyFile is the path to the PEM encoded private key file.
+	KeyFile string
 }
 
 type ResolverSettings struct {
 	DoHProviders []provider.Provider
 	SelfDNS      SelfDNS
 	Timeout      time.Duration
+	// Strategy is the strategy to pick the upstream server
+	// to send each query to, and defaults to random.
+	Strategy upstream.Strategy
+	// Race is the number of upstream servers each query is sent to
+	// at once, using the first response which is not SERVFAIL.
+	// It defaults to 1, which disables racing queries.
+	Race int
 }
 
 type SelfDNS struct {
@@ -31,20 +85,50 @@ type SelfDNS struct {
 	IPv6         bool
 }
 
-func (s *ServerSettings) setDefaults() {
-	s.Resolver.setDefaults()
+func (s *ServerSettings) SetDefaults() {
+	s.Resolver.SetDefaults()
 
 	if s.Port == 0 {
 		const defaultPort = 53
 		s.Port = defaultPort
 	}
 
+	s.HTTP.SetDefaults()
+
 	// Cache defaults to disabled, see pkg/cache/settings.go
+	if s.Metrics == nil {
+		s.Metrics = metrics.NewNoop()
+	}
+
+	if s.Cache.LRU.Metrics == nil {
+		s.Cache.LRU.Metrics = s.Metrics
+	}
 	s.Cache.SetDefaults()
+
+	s.BlockResponse.SetDefaults()
+
+	s.QueryLog.SetDefaults()
+
+	for i := range s.Policies {
+		if len(s.Policies[i].DoHProviders) == 0 {
+			s.Policies[i].DoHProviders = s.Resolver.DoHProviders
+		}
+	}
+}
+
+func (s *HTTPSettings) SetDefaults() {
+	if s.Port == 0 {
+		const defaultPort = 443
+		s.Port = defaultPort
+	}
+
+	if s.Path == "" {
+		s.Path = "/dns-query"
+	}
 }
 
-func (s *ResolverSettings) setDefaults() {
-	s.SelfDNS.setDefaults()
+func (s *ResolverSettings) SetDefaults() {
+	s.SelfDNS.SetDefaults()
 
 	if len(s.DoHProviders) == 0 {
 		s.DoHProviders = []provider.Provider{provider.Cloudflare()}
@@ -54,9 +138,17 @@ func (s *ResolverSettings) setDefaults() {
 		const defaultTimeout = 5 * time.Second
 		s.Timeout = defaultTimeout
 	}
+
+	if s.Strategy == "" {
+		s.Strategy = upstream.Random
+	}
+
+	if s.Race == 0 {
+		s.Race = 1
+	}
 }
 
-func (s *SelfDNS) setDefaults() {
+func (s *SelfDNS) SetDefaults() {
 	if s.Timeout == 0 {
 		const defaultTimeout = 5 * time.Second
 		s.Timeout = defaultTimeout
@@ -82,6 +174,14 @@ func (s *ResolverSettings) String() string {
 	return strings.Join(s.Lines(indent, subSection), "\n")
 }
 
+func (s *PolicySettings) String() string {
+	return strings.Join(s.Lines(indent, subSection), "\n")
+}
+
+func (s *HTTPSettings) String() string {
+	return strings.Join(s.Lines(indent, subSection), "\n")
+}
+
 func (s *SelfDNS) String() string {
 	return strings.Join(s.Lines(indent, subSection), "\n")
 }
@@ -90,6 +190,15 @@ func (s *ServerSettings) Lines(indent, subSection string) (lines []string) {
 	lines = append(lines,
 		subSection+"Listening port: "+strconv.Itoa(int(s.Port)))
 
+	if s.HTTP.Enabled {
+		lines = append(lines, subSection+"DNS over HTTPS listener:")
+		for _, line := range s.HTTP.Lines(indent, subSection) {
+			lines = append(lines, indent+line)
+		}
+	} else {
+		lines = append(lines, subSection+"DNS over HTTPS listener: disabled")
+	}
+
 	lines = append(lines, subSection+"Resolver:")
 	for _, line := range s.Resolver.Lines(indent, subSection) {
 		lines = append(lines, indent+line)
@@ -105,6 +214,73 @@ func (s *ServerSettings) Lines(indent, subSection string) (lines []string) {
 		lines = append(lines, indent+line)
 	}
 
+	lines = append(lines, subSection+"Block response:")
+	for _, line := range s.BlockResponse.Lines(indent, subSection) {
+		lines = append(lines, indent+line)
+	}
+
+	lines = append(lines, subSection+"Query log:")
+	for _, line := range s.QueryLog.Lines(indent, subSection) {
+		lines = append(lines, indent+line)
+	}
+
+	if s.DNSSEC.Enabled {
+		lines = append(lines, subSection+"DNSSEC validation:")
+		for _, line := range s.DNSSEC.Lines(indent, subSection) {
+			lines = append(lines, indent+line)
+		}
+	} else {
+		lines = append(lines, subSection+"DNSSEC validation: disabled")
+	}
+
+	if len(s.Policies) > 0 {
+		lines = append(lines, subSection+"Client policies:")
+		for _, policy := range s.Policies {
+			lines = append(lines, indent+subSection+"Policy "+policy.Name+":")
+			for _, line := range policy.Lines(indent, subSection) {
+				lines = append(lines, indent+indent+line)
+			}
+		}
+	}
+
+	return lines
+}
+
+func (s *PolicySettings) Lines(indent, subSection string) (lines []string) {
+	subnets := make([]string, len(s.Subnets))
+	for i, subnet := range s.Subnets {
+		subnets[i] = subnet.String()
+	}
+	lines = append(lines, subSection+"Subnets: "+strings.Join(subnets, ", "))
+
+	lines = append(lines, subSection+"DNS over HTTPS providers:")
+	for _, provider := range s.DoHProviders {
+		lines = append(lines, indent+subSection+provider.String())
+	}
+
+	lines = append(lines, subSection+"Blacklist:")
+	for _, line := range s.Blacklist.Lines(indent, subSection) {
+		lines = append(lines, indent+line)
+	}
+
+	return lines
+}
+
+func (s *HTTPSettings) Lines(indent, subSection string) (lines []string) {
+	lines = append(lines,
+		subSection+"Listening port: "+strconv.Itoa(int(s.Port)))
+	lines = append(lines, subSection+"Path: "+s.Path)
+
+	if s.CertificateFile == "" && s.KeyFile == "" {
+		lines = append(lines, subSection+"TLS: disabled")
+		return lines
+	}
+
+	lines = append(lines,
+		subSection+"Certificate file: "+s.CertificateFile)
+	lines = append(lines,
+		subSection+"Key file: "+s.KeyFile)
+
 	return lines
 }
 
@@ -112,6 +288,16 @@ func (s *ResolverSettings) Lines(indent, subSection string) (lines []string) {
 	lines = append(lines,
 		subSection+"Query timeout: "+s.Timeout.String())
 
+	lines = append(lines,
+		subSection+"Upstream selection: "+string(s.Strategy))
+
+	if s.Race > 1 {
+		lines = append(lines, subSection+"Race queries: to "+
+			strconv.Itoa(s.Race)+" upstream servers")
+	} else {
+		lines = append(lines, subSection+"Race queries: disabled")
+	}
+
 	lines = append(lines, subSection+"DNS over HTTPS providers:")
 	for _, provider := range s.DoHProviders {
 		lines = append(lines, indent+subSection+provider.String())
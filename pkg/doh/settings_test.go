@@ -6,15 +6,20 @@ import (
 
 	"github.com/qdm12/dns/pkg/blacklist"
 	"github.com/qdm12/dns/pkg/cache"
+	"github.com/qdm12/dns/pkg/cache/lru"
+	"github.com/qdm12/dns/pkg/metrics"
 	"github.com/qdm12/dns/pkg/provider"
+	"github.com/qdm12/dns/pkg/querylog"
+	"github.com/qdm12/dns/pkg/upstream"
 	"github.com/stretchr/testify/assert"
+	"inet.af/netaddr"
 )
 
-func Test_ServerSettings_setDefaults(t *testing.T) {
+func Test_ServerSettings_SetDefaults(t *testing.T) {
 	t.Parallel()
 
 	s := ServerSettings{}
-	s.setDefaults()
+	s.SetDefaults()
 
 	// Check this otherwise things will blow up if no option is passed.
 	assert.GreaterOrEqual(t, len(s.Resolver.DoHProviders), 1)
@@ -30,12 +35,35 @@ func Test_ServerSettings_setDefaults(t *testing.T) {
 				Timeout:      5 * time.Second,
 				IPv6:         false,
 			},
-			Timeout: 5 * time.Second,
+			Timeout:  5 * time.Second,
+			Strategy: upstream.Random,
+			Race:     1,
 		},
 		Port: 53,
+		HTTP: HTTPSettings{
+			Port: 443,
+			Path: "/dns-query",
+		},
 		Cache: cache.Settings{
 			Type: cache.Disabled,
+			LRU: lru.Settings{
+				Metrics: metrics.NewNoop(),
+			},
+		},
+		BlockResponse: blacklist.ResponseSettings{
+			Mode:         blacklist.ResponseRefused,
+			SinkholeIPv4: netaddr.IPv4(0, 0, 0, 0),
+			SinkholeIPv6: netaddr.IPv6Unspecified(),
+			TTL:          3600,
+		},
+		QueryLog: querylog.Settings{
+			File: querylog.FileSettings{
+				MaxSize:    10000000,
+				MaxBackups: 3,
+			},
+			Anonymize: querylog.AnonymizeNone,
 		},
+		Metrics: metrics.NewNoop(),
 	}
 	assert.Equal(t, expectedSettings, s)
 }
@@ -51,14 +79,17 @@ func Test_ServerSettings_Lines(t *testing.T) {
 			Type: cache.LRU,
 		},
 	}
-	s.setDefaults()
+	s.SetDefaults()
 
 	lines := s.Lines(indent, subSection)
 
 	expectedLines := []string{
 		" |--Listening port: 53",
+		" |--DNS over HTTPS listener: disabled",
 		" |--Resolver:",
 		"     |--Query timeout: 5s",
+		"     |--Upstream selection: random",
+		"     |--Race queries: disabled",
 		"     |--DNS over HTTPS providers:",
 		"         |--Cloudflare",
 		"     |--Internal DNS:",
@@ -69,8 +100,20 @@ func Test_ServerSettings_Lines(t *testing.T) {
 		" |--Caching:",
 		"     |--Type: lru",
 		"     |--Max entries: 100000",
+		"     |--Max size: unlimited",
+		"     |--Min TTL: none",
+		"     |--Max TTL: 24h0m0s",
+		"     |--Negative responses max TTL: 3h0m0s",
+		"     |--SERVFAIL responses TTL: 30s",
+		"     |--Serve stale: disabled",
+		"     |--Prefetch: disabled",
 		" |--Blacklist:",
 		"     |--Hostnames blocked: 1",
+		" |--Block response:",
+		"     |--Mode: refused",
+		" |--Query log:",
+		"     |--Query logging is disabled",
+		" |--DNSSEC validation: disabled",
 	}
 	assert.Equal(t, expectedLines, lines)
 }
@@ -0,0 +1,139 @@
+package dot
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/qdm12/dns/pkg/upstream"
+)
+
+func newPipelineConn(ctx context.Context, pipe *pipeline,
+	upstreamPicker upstream.Picker, address, provider string) net.Conn {
+	return &pipelineConn{
+		ctx:      ctx,
+		pipeline: pipe,
+		upstream: upstreamPicker,
+		address:  address,
+		provider: provider,
+	}
+}
+
+// pipelineConn is a net.Conn implementation for a single DNS
+// exchange done over a shared pipelined connection.
+// Written bytes are expected to be DNS messages prefixed with their
+// two bytes length, as for any stream oriented DNS connection.
+// Closing it does not close the shared pipelined connection.
+// The result of the exchange is reported to the upstream picker.
+type pipelineConn struct {
+	// External objects injected at creation
+	ctx      context.Context
+	pipeline *pipeline
+	upstream upstream.Picker
+	address  string // upstream address reported to the picker
+	provider string // provider name of the upstream server
+
+	// Internals
+	inBuffer  bytes.Buffer
+	outBuffer bytes.Buffer
+	deadline  time.Time
+}
+
+func (c *pipelineConn) Read(b []byte) (n int, err error) {
+	if c.outBuffer.Len() > 0 {
+		// We had the result of a previous exchange,
+		// so return it here.
+		return c.outBuffer.Read(b)
+	}
+
+	const lengthPrefixSize = 2
+	if c.inBuffer.Len() < lengthPrefixSize {
+		return 0, ErrMessageTooShort
+	}
+	length := int(binary.BigEndian.Uint16(c.inBuffer.Next(lengthPrefixSize)))
+	if c.inBuffer.Len() < length {
+		return 0, ErrMessageTooShort
+	}
+	query := c.inBuffer.Next(length)
+
+	exchangeStart := time.Now()
+	response, err := c.pipeline.exchange(c.ctx, c.deadline, query)
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			c.upstream.Failure(c.address, err)
+		}
+		return 0, err
+	}
+	c.upstream.Success(c.address, time.Since(exchangeStart))
+
+	lengthBytes := make([]byte, lengthPrefixSize)
+	binary.BigEndian.PutUint16(lengthBytes, uint16(len(response)))
+	_, _ = c.outBuffer.Write(lengthBytes)
+	_, _ = c.outBuffer.Write(response)
+
+	return c.outBuffer.Read(b)
+}
+
+// Write only writes the bytes to send in the connection
+// to a buffer. The exchange is done in Read instead
+// such that response data can be read at the same time.
+func (c *pipelineConn) Write(b []byte) (n int, err error) {
+	return c.inBuffer.Write(b)
+}
+
+func (c *pipelineConn) Close() error {
+	return nil
+}
+
+// Provider returns the name of the provider of the upstream server.
+func (c *pipelineConn) Provider() string {
+	return c.provider
+}
+
+func (c *pipelineConn) LocalAddr() net.Addr {
+	return c.pipeline.conn.LocalAddr()
+}
+
+func (c *pipelineConn) RemoteAddr() net.Addr {
+	return c.pipeline.conn.RemoteAddr()
+}
+
+func (c *pipelineConn) SetDeadline(t time.Time) error {
+	if err := c.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return c.SetWriteDeadline(t)
+}
+
+func (c *pipelineConn) SetReadDeadline(t time.Time) error {
+	c.deadline = t
+	return nil
+}
+
+func (c *pipelineConn) SetWriteDeadline(t time.Time) error {
+	// IO happens in read only so no timeout to set here
+	return nil
+}
+
+// providerConn is implemented by upstream connections
+// knowing the name of the provider of their upstream server.
+type providerConn interface {
+	Provider() string
+}
+
+// namedPacketConn is a plain DNS UDP connection to an upstream
+// server of the provider named. It embeds the UDP connection so
+// it still implements net.PacketConn, for dns.Conn to exchange
+// messages without the length prefix used over streams.
+type namedPacketConn struct {
+	*net.UDPConn
+	provider string
+}
+
+// Provider returns the name of the provider of the upstream server.
+func (c *namedPacketConn) Provider() string {
+	return c.provider
+}
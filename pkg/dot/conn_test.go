@@ -0,0 +1,53 @@
+package dot
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_namedPacketConn(t *testing.T) {
+	t.Parallel()
+
+	packetConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	answer := &dns.A{
+		Hdr: dns.RR_Header{Name: "github.com.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 30},
+		A:   net.IP{1, 2, 3, 4},
+	}
+	server := &dns.Server{
+		PacketConn: packetConn,
+		Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+			response := new(dns.Msg).SetReply(r)
+			response.Answer = []dns.RR{answer}
+			_ = w.WriteMsg(response)
+		}),
+	}
+	started := make(chan struct{})
+	server.NotifyStartedFunc = func() { close(started) }
+	go func() { _ = server.ActivateAndServe() }()
+	<-started
+	defer func() { _ = server.Shutdown() }()
+
+	udpConn, err := net.DialUDP("udp", nil, packetConn.LocalAddr().(*net.UDPAddr))
+	require.NoError(t, err)
+	var conn net.Conn = &namedPacketConn{UDPConn: udpConn, provider: "local"}
+	defer conn.Close()
+
+	_, isPacketConn := conn.(net.PacketConn)
+	assert.True(t, isPacketConn)
+	assert.Equal(t, "local", providerName(conn))
+
+	client := &dns.Client{Timeout: time.Second}
+	request := new(dns.Msg).SetQuestion("github.com.", dns.TypeA)
+	response, _, err := client.ExchangeWithConn(request, &dns.Conn{Conn: conn})
+
+	require.NoError(t, err)
+	require.Len(t, response.Answer, 1)
+	assert.Equal(t, answer.String(), response.Answer[0].String())
+}
@@ -4,14 +4,27 @@ import (
 	"context"
 	"crypto/tls"
 	"net"
-	"strconv"
+	"sync"
 
 	"github.com/qdm12/dns/pkg/provider"
+	"github.com/qdm12/dns/pkg/upstream"
 )
 
 type dialFunc func(ctx context.Context, _, _ string) (net.Conn, error)
 
-func newDoTDial(settings ResolverSettings) dialFunc {
+// raceDialFunc dials up to n distinct upstream servers at once, and
+// returns the connections dialed successfully.
+type raceDialFunc func(ctx context.Context, n int) (conns []net.Conn, err error)
+
+// maxUpstreamAttempts is the maximum number of upstream servers
+// tried to dial or to exchange a query with, before giving up.
+const maxUpstreamAttempts = 3
+
+// newDoTDial returns a function to dial an upstream server, and a
+// function to dial multiple upstream servers for racing queries,
+// sharing the same connection pool and upstream picker.
+func newDoTDial(settings ResolverSettings) (dial dialFunc, raceDial raceDialFunc,
+	upstreamPicker upstream.Picker) {
 	dotServers := make([]provider.DoTServer, len(settings.DoTProviders))
 	for i := range settings.DoTProviders {
 		dotServers[i] = settings.DoTProviders[i].DoT()
@@ -26,30 +39,97 @@ func newDoTDial(settings ResolverSettings) dialFunc {
 		Timeout: settings.Timeout,
 	}
 
-	picker := newPicker()
+	// One TLS configuration per server name, to share the
+	// TLS session cache and resume TLS sessions on new connections.
+	tlsConfigs := make(map[string]*tls.Config, len(dotServers))
+	for _, dotServer := range dotServers {
+		if _, ok := tlsConfigs[dotServer.Name]; ok {
+			continue
+		}
+		tlsConfigs[dotServer.Name] = &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			ServerName:         dotServer.Name,
+			ClientSessionCache: tls.NewLRUClientSessionCache(0),
+		}
+	}
 
-	return func(ctx context.Context, _, _ string) (net.Conn, error) {
-		DoTServer := picker.DoTServer(dotServers)
-		ip := picker.DoTIP(DoTServer, settings.IPv6)
-		tlsAddr := net.JoinHostPort(ip.String(), strconv.Itoa(int(DoTServer.Port)))
+	pool := newPool(dialer, settings.IdleTimeout)
 
-		conn, err := dialer.DialContext(ctx, "tcp", tlsAddr)
-		if err != nil {
-			if len(dnsServers) > 0 {
-				// fallback on plain DNS if DoT does not work
-				dnsServer := picker.DNSServer(dnsServers)
-				ip := picker.DNSIP(dnsServer, settings.IPv6)
-				plainAddr := net.JoinHostPort(ip.String(), "53")
-				return dialer.DialContext(ctx, "udp", plainAddr)
+	picker := newPicker(settings.Strategy)
+
+	dial = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		var err error
+		for attempt := 0; attempt < maxUpstreamAttempts; attempt++ {
+			index, tlsAddr := picker.DoTUpstream(dotServers, settings.IPv6)
+
+			var pipe *pipeline
+			pipe, err = pool.get(ctx, tlsAddr, tlsConfigs[dotServers[index].Name])
+			if err == nil {
+				return newPipelineConn(ctx, pipe, picker.upstream, tlsAddr,
+					settings.DoTProviders[index].String()), nil
 			}
-			return nil, err
+
+			picker.upstream.Failure(tlsAddr, err)
+			if ctx.Err() != nil {
+				break
+			}
+			// retry on another upstream, the failing one being in cool-down
 		}
 
-		tlsConf := &tls.Config{
-			MinVersion: tls.VersionTLS12,
-			ServerName: DoTServer.Name,
+		if len(dnsServers) > 0 {
+			// fallback on plain DNS if DoT does not work
+			index := picker.DNSServer(dnsServers)
+			ip := picker.DNSIP(dnsServers[index], settings.IPv6)
+			plainAddr := net.JoinHostPort(ip.String(), "53")
+			conn, err := dialer.DialContext(ctx, "udp", plainAddr)
+			if err != nil {
+				return nil, err
+			}
+			return &namedPacketConn{
+				UDPConn:  conn.(*net.UDPConn),
+				provider: settings.DNSProviders[index].String(),
+			}, nil
 		}
-		// TODO handshake? See tls.DialWithDialer
-		return tls.Client(conn, tlsConf), nil
+		return nil, err
 	}
+
+	raceDial = func(ctx context.Context, n int) (conns []net.Conn, err error) {
+		indexes, addresses := picker.DoTUpstreams(dotServers, settings.IPv6, n)
+
+		dialed := make([]net.Conn, len(addresses))
+		var wg sync.WaitGroup
+		for i := range addresses {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				dotServer := dotServers[indexes[i]]
+				pipe, err := pool.get(ctx, addresses[i], tlsConfigs[dotServer.Name])
+				if err != nil {
+					picker.upstream.Failure(addresses[i], err)
+					return
+				}
+				dialed[i] = newPipelineConn(ctx, pipe, picker.upstream, addresses[i],
+					settings.DoTProviders[indexes[i]].String())
+			}(i)
+		}
+		wg.Wait()
+
+		for _, conn := range dialed {
+			if conn != nil {
+				conns = append(conns, conn)
+			}
+		}
+		if len(conns) > 0 {
+			return conns, nil
+		}
+
+		// fall back on dialing a single upstream with retries
+		conn, err := dial(ctx, "", "")
+		if err != nil {
+			return nil, err
+		}
+		return []net.Conn{conn}, nil
+	}
+
+	return dial, raceDial, picker.upstream
 }
@@ -0,0 +1,549 @@
+package dot
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/blacklist"
+	"github.com/qdm12/dns/pkg/cache"
+	"github.com/qdm12/dns/pkg/cache/mock_cache"
+	"github.com/qdm12/dns/pkg/metrics/mock_metrics"
+	"github.com/qdm12/dns/pkg/querylog"
+	"github.com/qdm12/golibs/logging/mock_logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"inet.af/netaddr"
+)
+
+type testResponseWriter struct {
+	remoteAddr net.Addr
+	response   *dns.Msg
+}
+
+func (w *testResponseWriter) LocalAddr() net.Addr { return &net.UDPAddr{} }
+func (w *testResponseWriter) RemoteAddr() net.Addr {
+	if w.remoteAddr == nil {
+		return &net.UDPAddr{}
+	}
+	return w.remoteAddr
+}
+func (w *testResponseWriter) WriteMsg(response *dns.Msg) error {
+	w.response = response
+	return nil
+}
+func (w *testResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *testResponseWriter) Close() error                { return nil }
+func (w *testResponseWriter) TsigStatus() error           { return nil }
+func (w *testResponseWriter) TsigTimersOnly(bool)         {}
+func (w *testResponseWriter) Hijack()                     {}
+
+func Test_handler_UpdateBlacklist(t *testing.T) {
+	t.Parallel()
+
+	settings := ServerSettings{}
+	settings.SetDefaults()
+	settings.Blacklist.BlockHostnames([]string{"github.com"})
+	handler := newDNSHandler(context.Background(), nil, settings)
+
+	request := new(dns.Msg).SetQuestion("github.com.", dns.TypeA)
+	writer := &testResponseWriter{}
+	handler.ServeDNS(writer, request)
+	require.NotNil(t, writer.response)
+	assert.Equal(t, dns.RcodeRefused, writer.response.Rcode)
+
+	newSettings := blacklist.Settings{}
+	newSettings.BlockHostnames([]string{"github.com", "google.com"})
+	handler.UpdateBlacklist(newSettings)
+
+	request = new(dns.Msg).SetQuestion("google.com.", dns.TypeA)
+	writer = &testResponseWriter{}
+	handler.ServeDNS(writer, request)
+	require.NotNil(t, writer.response)
+	assert.Equal(t, dns.RcodeRefused, writer.response.Rcode)
+}
+
+func Test_handler_UpdateBlacklist_cached(t *testing.T) {
+	t.Parallel()
+
+	settings := ServerSettings{}
+	settings.Cache.Type = cache.LRU
+	settings.SetDefaults()
+	handler := newDNSHandler(context.Background(), nil, settings)
+
+	answer := &dns.A{
+		Hdr: dns.RR_Header{Name: "github.com.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+		A:   net.IP{1, 2, 3, 4},
+	}
+	handler.defaultPolicy.dial = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return newTestUpstreamConn(answer, dns.RcodeSuccess), nil
+	}
+
+	request := new(dns.Msg).SetQuestion("github.com.", dns.TypeA)
+	writer := &testResponseWriter{}
+	handler.ServeDNS(writer, request)
+	require.NotNil(t, writer.response)
+	assert.Equal(t, dns.RcodeSuccess, writer.response.Rcode)
+	require.Equal(t, 1, handler.defaultPolicy.cache.Len())
+
+	testCases := map[string]func(settings *blacklist.Settings){
+		"hostname": func(settings *blacklist.Settings) {
+			settings.BlockHostnames([]string{"github.com"})
+		},
+		"IP": func(settings *blacklist.Settings) {
+			settings.IPs = []netaddr.IP{netaddr.IPv4(1, 2, 3, 4)}
+		},
+	}
+
+	for name, block := range testCases {
+		var newSettings blacklist.Settings
+		block(&newSettings)
+		handler.UpdateBlacklist(newSettings)
+
+		request = new(dns.Msg).SetQuestion("github.com.", dns.TypeA)
+		writer = &testResponseWriter{}
+		handler.ServeDNS(writer, request)
+		require.NotNil(t, writer.response, name)
+		assert.Equal(t, dns.RcodeRefused, writer.response.Rcode, name)
+	}
+}
+
+func Test_handler_policyFor(t *testing.T) {
+	t.Parallel()
+
+	settings := ServerSettings{
+		Policies: []PolicySettings{
+			{
+				Name:    "kids",
+				Subnets: []netaddr.IPPrefix{netaddr.MustParseIPPrefix("10.0.1.0/24")},
+			},
+			{
+				Name: "office",
+				Subnets: []netaddr.IPPrefix{
+					netaddr.MustParseIPPrefix("10.0.0.0/16"),
+					netaddr.MustParseIPPrefix("fd00::/8"),
+				},
+			},
+		},
+	}
+	settings.SetDefaults()
+	handler := newDNSHandler(context.Background(), nil, settings)
+
+	testCases := map[string]struct {
+		clientAddr net.Addr
+		policyName string
+	}{
+		"no match": {
+			clientAddr: &net.UDPAddr{IP: net.IP{192, 168, 1, 1}},
+			policyName: "default",
+		},
+		"first policy": {
+			clientAddr: &net.UDPAddr{IP: net.IP{10, 0, 1, 5}},
+			policyName: "kids",
+		},
+		"second policy over TCP": {
+			clientAddr: &net.TCPAddr{IP: net.IP{10, 0, 2, 5}},
+			policyName: "office",
+		},
+		"IPv4 mapped IPv6": {
+			clientAddr: &net.UDPAddr{IP: net.ParseIP("::ffff:10.0.1.5")},
+			policyName: "kids",
+		},
+		"IPv6": {
+			clientAddr: &net.UDPAddr{IP: net.ParseIP("fd00::1")},
+			policyName: "office",
+		},
+		"unknown address type": {
+			clientAddr: &net.UnixAddr{},
+			policyName: "default",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			policy := handler.policyFor(testCase.clientAddr)
+			assert.Equal(t, testCase.policyName, policy.name)
+		})
+	}
+}
+
+func Test_handler_UpdatePolicyBlacklist(t *testing.T) {
+	t.Parallel()
+
+	settings := ServerSettings{
+		Policies: []PolicySettings{{
+			Name:    "kids",
+			Subnets: []netaddr.IPPrefix{netaddr.MustParseIPPrefix("10.0.0.0/8")},
+		}},
+	}
+	settings.SetDefaults()
+	settings.Blacklist.BlockHostnames([]string{"github.com"})
+	handler := newDNSHandler(context.Background(), nil, settings)
+
+	err := handler.UpdatePolicyBlacklist("unknown", blacklist.Settings{})
+	assert.ErrorIs(t, err, ErrPolicyNotFound)
+
+	policySettings := blacklist.Settings{}
+	policySettings.BlockHostnames([]string{"youtube.com"})
+	err = handler.UpdatePolicyBlacklist("kids", policySettings)
+	require.NoError(t, err)
+
+	request := new(dns.Msg).SetQuestion("youtube.com.", dns.TypeA)
+	writer := &testResponseWriter{remoteAddr: &net.UDPAddr{IP: net.IP{10, 1, 2, 3}}}
+	handler.ServeDNS(writer, request)
+	require.NotNil(t, writer.response)
+	assert.Equal(t, dns.RcodeRefused, writer.response.Rcode)
+
+	request = new(dns.Msg).SetQuestion("github.com.", dns.TypeA)
+	writer = &testResponseWriter{remoteAddr: &net.UDPAddr{IP: net.IP{192, 168, 1, 1}}}
+	handler.ServeDNS(writer, request)
+	require.NotNil(t, writer.response)
+	assert.Equal(t, dns.RcodeRefused, writer.response.Rcode)
+}
+
+func Test_handler_queryLog(t *testing.T) {
+	t.Parallel()
+
+	settings := ServerSettings{}
+	settings.QueryLog.RingSize = 10
+	settings.QueryLog.Anonymize = querylog.AnonymizeTruncate
+	settings.SetDefaults()
+	settings.Blacklist.BlockHostnames([]string{"github.com"})
+	handler := newDNSHandler(context.Background(), nil, settings)
+
+	request := new(dns.Msg).SetQuestion("github.com.", dns.TypeAAAA)
+	writer := &testResponseWriter{remoteAddr: &net.UDPAddr{IP: net.IP{10, 1, 2, 3}}}
+	handler.ServeDNS(writer, request)
+
+	entries := handler.queryRing.Entries()
+	require.Len(t, entries, 1)
+	entry := entries[0]
+	assert.False(t, entry.Time.IsZero())
+	assert.Equal(t, "10.1.2.0", entry.ClientIP)
+	assert.Equal(t, "github.com.", entry.Name)
+	assert.Equal(t, "AAAA", entry.Type)
+	assert.Equal(t, "REFUSED", entry.Rcode)
+	assert.Empty(t, entry.Upstream)
+	assert.False(t, entry.CacheHit)
+	assert.Equal(t, querylog.BlockedHostname, entry.Blocked)
+}
+
+func Test_handler_serveStale(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		stale *dns.Msg
+		rcode int
+	}{
+		"answer": {
+			stale: &dns.Msg{Answer: []dns.RR{&dns.A{
+				Hdr: dns.RR_Header{Name: "github.com.", Rrtype: dns.TypeA, Ttl: 30},
+				A:   net.IP{1, 2, 3, 4},
+			}}},
+			rcode: dns.RcodeSuccess,
+		},
+		"NXDOMAIN": {
+			stale: newTestNXDomain(new(dns.Msg).SetQuestion("github.com.", dns.TypeA)),
+			rcode: dns.RcodeNameError,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+
+			settings := ServerSettings{}
+			settings.SetDefaults()
+			logger := mock_logging.NewMockLogger(mockCtrl)
+			logger.EXPECT().Warn("cannot dial: dial failed")
+			handler := newDNSHandler(context.Background(), logger, settings)
+
+			request := new(dns.Msg).SetQuestion("github.com.", dns.TypeA)
+			stale := testCase.stale
+
+			cache := mock_cache.NewMockCache(mockCtrl)
+			cache.EXPECT().Get(request).Return(nil, false)
+			cache.EXPECT().GetStale(request).Return(stale)
+			handler.defaultPolicy.cache = cache
+			handler.defaultPolicy.dial = func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return nil, errors.New("dial failed")
+			}
+
+			writer := &testResponseWriter{}
+			handler.ServeDNS(writer, request)
+
+			require.NotNil(t, writer.response)
+			assert.Equal(t, testCase.rcode, writer.response.Rcode)
+			assert.Equal(t, request.Id, writer.response.Id)
+			assert.Equal(t, testCase.stale.Answer, writer.response.Answer)
+			assert.Equal(t, testCase.stale.Ns, writer.response.Ns)
+		})
+	}
+}
+
+// newTestUpstreamConn returns a connection to a fake upstream server
+// answering one query with the answer given and the rcode given.
+func newTestUpstreamConn(answer dns.RR, rcode int) net.Conn {
+	return newTestRespondConn(func(query *dns.Msg) *dns.Msg {
+		response := new(dns.Msg).SetRcode(query, rcode)
+		if rcode == dns.RcodeSuccess {
+			response.Answer = []dns.RR{answer}
+		}
+		return response
+	})
+}
+
+// newTestRespondConn returns a connection to a fake upstream server
+// answering one query with the response returned by respond.
+func newTestRespondConn(respond func(query *dns.Msg) *dns.Msg) net.Conn {
+	clientConn, serverConn := net.Pipe()
+	go func() {
+		conn := &dns.Conn{Conn: serverConn}
+		defer conn.Close()
+		query, err := conn.ReadMsg()
+		if err != nil {
+			return
+		}
+		_ = conn.WriteMsg(respond(query))
+	}()
+	return clientConn
+}
+
+// newTestNXDomain returns an NXDOMAIN response to the query,
+// with an SOA record so it can be cached.
+func newTestNXDomain(query *dns.Msg) *dns.Msg {
+	response := new(dns.Msg).SetRcode(query, dns.RcodeNameError)
+	response.Ns = []dns.RR{&dns.SOA{
+		Hdr: dns.RR_Header{Name: "com.", Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: 900},
+		Ns:  "a.gtld-servers.net.", Mbox: "nstld.verisign-grs.com.",
+		Minttl: 900,
+	}}
+	return response
+}
+
+func Test_handler_cachedNXDomain(t *testing.T) {
+	t.Parallel()
+
+	settings := ServerSettings{}
+	settings.Cache.Type = cache.LRU
+	settings.SetDefaults()
+	handler := newDNSHandler(context.Background(), nil, settings)
+
+	dials := 0
+	handler.defaultPolicy.dial = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		dials++
+		return newTestRespondConn(newTestNXDomain), nil
+	}
+
+	for i := 0; i < 2; i++ {
+		request := new(dns.Msg).SetQuestion("nx.github.com.", dns.TypeA)
+		writer := &testResponseWriter{}
+		handler.ServeDNS(writer, request)
+
+		require.NotNil(t, writer.response)
+		assert.Equal(t, dns.RcodeNameError, writer.response.Rcode)
+		assert.Equal(t, request.Id, writer.response.Id)
+		assert.Len(t, writer.response.Ns, 1)
+	}
+	assert.Equal(t, 1, dials)
+}
+
+func Test_handler_exchangeRetry(t *testing.T) {
+	t.Parallel()
+
+	settings := ServerSettings{}
+	settings.SetDefaults()
+	handler := newDNSHandler(context.Background(), nil, settings)
+
+	request := new(dns.Msg).SetQuestion("github.com.", dns.TypeA)
+	answer := &dns.A{
+		Hdr: dns.RR_Header{Name: "github.com.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 30},
+		A:   net.IP{1, 2, 3, 4},
+	}
+
+	dials := 0
+	handler.defaultPolicy.dial = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		dials++
+		if dials == 1 {
+			// first upstream fails the exchange
+			clientConn, serverConn := net.Pipe()
+			_ = serverConn.Close()
+			return clientConn, nil
+		}
+		return newTestUpstreamConn(answer, dns.RcodeSuccess), nil
+	}
+
+	var entry querylog.Entry
+	response, err := handler.exchange(handler.defaultPolicy, request, &entry)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, dials)
+	require.Len(t, response.Answer, 1)
+	assert.Equal(t, answer.String(), response.Answer[0].String())
+}
+
+func Test_handler_race(t *testing.T) {
+	t.Parallel()
+
+	settings := ServerSettings{}
+	settings.Resolver.Race = 3
+	settings.SetDefaults()
+	handler := newDNSHandler(context.Background(), nil, settings)
+
+	request := new(dns.Msg).SetQuestion("github.com.", dns.TypeA)
+	answer := &dns.A{
+		Hdr: dns.RR_Header{Name: "github.com.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 30},
+		A:   net.IP{1, 2, 3, 4},
+	}
+
+	handler.defaultPolicy.raceDial = func(ctx context.Context, n int) ([]net.Conn, error) {
+		assert.Equal(t, 3, n)
+		hangingConn, _ := net.Pipe() // never answers
+		return []net.Conn{
+			hangingConn,
+			newTestUpstreamConn(answer, dns.RcodeServerFailure),
+			newTestUpstreamConn(answer, dns.RcodeSuccess),
+		}, nil
+	}
+
+	var entry querylog.Entry
+	response, err := handler.exchange(handler.defaultPolicy, request, &entry)
+
+	require.NoError(t, err)
+	assert.Equal(t, dns.RcodeSuccess, response.Rcode)
+	require.Len(t, response.Answer, 1)
+	assert.Equal(t, answer.String(), response.Answer[0].String())
+}
+
+// namedConn is a connection to an upstream server
+// of the provider named.
+type namedConn struct {
+	net.Conn
+	provider string
+}
+
+func (c *namedConn) Provider() string {
+	return c.provider
+}
+
+// closeNotifyConn signals on its closed channel once closed.
+type closeNotifyConn struct {
+	*namedConn
+	closed chan struct{}
+}
+
+func (c *closeNotifyConn) Close() error {
+	err := c.namedConn.Close()
+	close(c.closed)
+	return err
+}
+
+func Test_handler_raceLatency(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+
+	metrics := mock_metrics.NewMockInterface(ctrl)
+	metrics.EXPECT().UpstreamLatency("winner", gomock.Any())
+	// the loser exchange is only recorded if it finishes before
+	// the race is over, which is not deterministic.
+	metrics.EXPECT().UpstreamLatency("servfail", gomock.Any()).MaxTimes(1)
+
+	settings := ServerSettings{Metrics: metrics}
+	settings.Resolver.Race = 3
+	settings.SetDefaults()
+	handler := newDNSHandler(context.Background(), nil, settings)
+
+	request := new(dns.Msg).SetQuestion("github.com.", dns.TypeA)
+	answer := &dns.A{
+		Hdr: dns.RR_Header{Name: "github.com.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 30},
+		A:   net.IP{1, 2, 3, 4},
+	}
+
+	hangingConn, hangingServerConn := net.Pipe()
+	loser := &closeNotifyConn{
+		namedConn: &namedConn{Conn: hangingConn, provider: "loser"},
+		closed:    make(chan struct{}),
+	}
+	servFail := &closeNotifyConn{
+		namedConn: &namedConn{
+			Conn:     newTestUpstreamConn(answer, dns.RcodeServerFailure),
+			provider: "servfail",
+		},
+		closed: make(chan struct{}),
+	}
+	handler.defaultPolicy.raceDial = func(ctx context.Context, n int) ([]net.Conn, error) {
+		return []net.Conn{
+			loser,
+			servFail,
+			&namedConn{Conn: newTestUpstreamConn(answer, dns.RcodeSuccess), provider: "winner"},
+		}, nil
+	}
+
+	var entry querylog.Entry
+	response, err := handler.exchange(handler.defaultPolicy, request, &entry)
+	require.NoError(t, err)
+	assert.Equal(t, dns.RcodeSuccess, response.Rcode)
+
+	// Fail the canceled exchange and wait for it to finish,
+	// without it recording its latency.
+	_ = hangingServerConn.Close()
+	<-loser.closed
+	<-servFail.closed
+}
+
+func Test_handler_dnssecBogus(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	settings := ServerSettings{}
+	settings.Cache.Type = cache.LRU
+	settings.DNSSEC.Enabled = true
+	settings.DNSSEC.TrustAnchors = []*dns.DS{{
+		Hdr:        dns.RR_Header{Name: ".", Rrtype: dns.TypeDS, Class: dns.ClassINET},
+		KeyTag:     20326,
+		Algorithm:  dns.RSASHA256,
+		DigestType: dns.SHA256,
+		Digest:     "e06d44b80b8f1d39a95c0b0d7c65d08458e880409bbc683457104237c7f8ec8d",
+	}}
+	settings.SetDefaults()
+	logger := mock_logging.NewMockLogger(mockCtrl)
+	logger.EXPECT().Warn("DNSSEC validation failed: no root DNSKEY record").Times(2)
+	handler := newDNSHandler(context.Background(), logger, settings)
+
+	// The upstream server answers all queries with an unsigned
+	// A record, so the root DNSKEY query fails validation.
+	dials := 0
+	handler.defaultPolicy.dial = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		dials++
+		return newTestRespondConn(func(query *dns.Msg) *dns.Msg {
+			response := new(dns.Msg).SetReply(query)
+			response.Answer = []dns.RR{&dns.A{
+				Hdr: dns.RR_Header{Name: "github.com.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 30},
+				A:   net.IP{1, 2, 3, 4},
+			}}
+			return response
+		}), nil
+	}
+
+	for i := 0; i < 2; i++ {
+		request := new(dns.Msg).SetQuestion("github.com.", dns.TypeA)
+		writer := &testResponseWriter{}
+		handler.ServeDNS(writer, request)
+
+		require.NotNil(t, writer.response)
+		assert.Equal(t, dns.RcodeServerFailure, writer.response.Rcode)
+		assert.Equal(t, request.Id, writer.response.Id)
+		assert.Empty(t, writer.response.Answer)
+	}
+	// the SERVFAIL response is not cached
+	const dialsPerQuery = 2 // query and root DNSKEY query
+	assert.Equal(t, 2*dialsPerQuery, dials)
+}
@@ -9,6 +9,10 @@ import (
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	blacklist "github.com/qdm12/dns/pkg/blacklist"
+	cache "github.com/qdm12/dns/pkg/cache"
+	querylog "github.com/qdm12/dns/pkg/querylog"
+	upstream "github.com/qdm12/dns/pkg/upstream"
 )
 
 // MockServer is a mock of Server interface.
@@ -34,6 +38,48 @@ func (m *MockServer) EXPECT() *MockServerMockRecorder {
 	return m.recorder
 }
 
+// Caches mocks base method.
+func (m *MockServer) Caches() map[string]cache.Cache {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Caches")
+	ret0, _ := ret[0].(map[string]cache.Cache)
+	return ret0
+}
+
+// Caches indicates an expected call of Caches.
+func (mr *MockServerMockRecorder) Caches() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Caches", reflect.TypeOf((*MockServer)(nil).Caches))
+}
+
+// FailingUpstreams mocks base method.
+func (m *MockServer) FailingUpstreams() map[string][]upstream.Health {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailingUpstreams")
+	ret0, _ := ret[0].(map[string][]upstream.Health)
+	return ret0
+}
+
+// FailingUpstreams indicates an expected call of FailingUpstreams.
+func (mr *MockServerMockRecorder) FailingUpstreams() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailingUpstreams", reflect.TypeOf((*MockServer)(nil).FailingUpstreams))
+}
+
+// QueryLog mocks base method.
+func (m *MockServer) QueryLog() []querylog.Entry {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueryLog")
+	ret0, _ := ret[0].([]querylog.Entry)
+	return ret0
+}
+
+// QueryLog indicates an expected call of QueryLog.
+func (mr *MockServerMockRecorder) QueryLog() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryLog", reflect.TypeOf((*MockServer)(nil).QueryLog))
+}
+
 // Run mocks base method.
 func (m *MockServer) Run(arg0 context.Context, arg1 chan<- error) {
 	m.ctrl.T.Helper()
@@ -45,3 +91,29 @@ func (mr *MockServerMockRecorder) Run(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockServer)(nil).Run), arg0, arg1)
 }
+
+// UpdateBlacklist mocks base method.
+func (m *MockServer) UpdateBlacklist(arg0 blacklist.Settings) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UpdateBlacklist", arg0)
+}
+
+// UpdateBlacklist indicates an expected call of UpdateBlacklist.
+func (mr *MockServerMockRecorder) UpdateBlacklist(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBlacklist", reflect.TypeOf((*MockServer)(nil).UpdateBlacklist), arg0)
+}
+
+// UpdatePolicyBlacklist mocks base method.
+func (m *MockServer) UpdatePolicyBlacklist(arg0 string, arg1 blacklist.Settings) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePolicyBlacklist", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePolicyBlacklist indicates an expected call of UpdatePolicyBlacklist.
+func (mr *MockServerMockRecorder) UpdatePolicyBlacklist(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePolicyBlacklist", reflect.TypeOf((*MockServer)(nil).UpdatePolicyBlacklist), arg0, arg1)
+}
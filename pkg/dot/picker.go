@@ -2,27 +2,65 @@ package dot
 
 import (
 	"net"
+	"strconv"
 
 	"github.com/qdm12/dns/pkg/provider"
+	"github.com/qdm12/dns/pkg/upstream"
 	"github.com/qdm12/golibs/crypto/random/hashmap"
 )
 
 type picker struct {
-	rand hashmap.Rand
+	rand     hashmap.Rand
+	upstream upstream.Picker
 }
 
-func newPicker() *picker {
+func newPicker(strategy upstream.Strategy) *picker {
 	return &picker{
-		rand: hashmap.New(),
+		rand:     hashmap.New(),
+		upstream: upstream.NewPicker(strategy),
 	}
 }
 
-func (p *picker) DoTServer(servers []provider.DoTServer) provider.DoTServer {
-	index := 0
-	if nServers := len(servers); nServers > 1 {
-		index = p.rand.Intn(nServers)
+// DoTUpstream picks a DoT server and one of its IP addresses using
+// the upstream picker, and returns the index of the server picked.
+// The candidate addresses are ordered as the servers given, and are
+// the IPv6 addresses of each server if ipv6 is true and it has any,
+// and its IPv4 addresses otherwise.
+func (p *picker) DoTUpstream(servers []provider.DoTServer, ipv6 bool) (
+	serverIndex int, address string) {
+	serverIndexes, addresses := p.DoTUpstreams(servers, ipv6, 1)
+	return serverIndexes[0], addresses[0]
+}
+
+// DoTUpstreams picks up to n distinct DoT server addresses as
+// DoTUpstream does, with the indexes of their corresponding DoT server.
+func (p *picker) DoTUpstreams(servers []provider.DoTServer, ipv6 bool, n int) (
+	serverIndexes []int, addresses []string) {
+	var candidateServerIndexes []int
+	var candidates []string
+	for i, server := range servers {
+		ips := server.IPv4
+		if ipv6 && len(server.IPv6) > 0 {
+			ips = server.IPv6
+		}
+		for _, ip := range ips {
+			candidateServerIndexes = append(candidateServerIndexes, i)
+			candidates = append(candidates, dotAddress(ip, server.Port))
+		}
+	}
+
+	indexes := p.upstream.PickN(candidates, n)
+	serverIndexes = make([]int, len(indexes))
+	addresses = make([]string, len(indexes))
+	for i, index := range indexes {
+		serverIndexes[i] = candidateServerIndexes[index]
+		addresses[i] = candidates[index]
 	}
-	return servers[index]
+	return serverIndexes, addresses
+}
+
+func dotAddress(ip net.IP, port uint16) string {
+	return net.JoinHostPort(ip.String(), strconv.Itoa(int(port)))
 }
 
 func (p *picker) IP(ips []net.IP) net.IP {
@@ -37,23 +75,12 @@ func (p *picker) IP(ips []net.IP) net.IP {
 	}
 }
 
-func (p *picker) DoTIP(server provider.DoTServer, ipv6 bool) net.IP {
-	if ipv6 {
-		if ip := p.IP(server.IPv6); ip != nil {
-			return ip
-		}
-		// if there is no IPv6, fall back to an IPv4 address
-		// as all provider have at least an IPv4 address.
-	}
-	return p.IP(server.IPv4)
-}
-
-func (p *picker) DNSServer(servers []provider.DNSServer) provider.DNSServer {
-	index := 0
+// DNSServer picks a plain DNS server at random and returns its index.
+func (p *picker) DNSServer(servers []provider.DNSServer) (index int) {
 	if nServers := len(servers); nServers > 1 {
 		index = p.rand.Intn(nServers)
 	}
-	return servers[index]
+	return index
 }
 
 func (p *picker) DNSIP(server provider.DNSServer, ipv6 bool) net.IP {
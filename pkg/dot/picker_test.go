@@ -0,0 +1,44 @@
+package dot
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/qdm12/dns/pkg/provider"
+	"github.com/qdm12/dns/pkg/upstream"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_picker_DoTUpstream(t *testing.T) {
+	t.Parallel()
+
+	first := provider.DoTServer{
+		Name: "first",
+		IPv4: []net.IP{{1, 1, 1, 1}},
+		Port: 853,
+	}
+	second := provider.DoTServer{
+		Name: "second",
+		IPv4: []net.IP{{2, 2, 2, 2}, {3, 3, 3, 3}},
+		IPv6: []net.IP{net.ParseIP("::2")},
+		Port: 853,
+	}
+	servers := []provider.DoTServer{first, second}
+
+	picker := newPicker(upstream.Failover)
+
+	index, address := picker.DoTUpstream(servers, false)
+	assert.Equal(t, 0, index)
+	assert.Equal(t, "1.1.1.1:853", address)
+
+	picker.upstream.Failure("1.1.1.1:853", errors.New("test error"))
+	index, address = picker.DoTUpstream(servers, false)
+	assert.Equal(t, 1, index)
+	assert.Equal(t, "2.2.2.2:853", address)
+
+	// IPv6 addresses are used for servers having any
+	index, address = picker.DoTUpstream(servers, true)
+	assert.Equal(t, 1, index)
+	assert.Equal(t, "[::2]:853", address)
+}
@@ -0,0 +1,227 @@
+package dot
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	ErrPipelineClosed  = errors.New("pipelined connection is closed")
+	ErrPipelineFull    = errors.New("pipelined connection has too many queries in flight")
+	ErrMessageTooShort = errors.New("DNS message is too short")
+	ErrMessageTooLarge = errors.New("DNS message is too large")
+	errIdleTimeout     = errors.New("idle timeout reached")
+)
+
+// pipeline is a single TCP+TLS connection to an upstream DoT server
+// on which multiple DNS queries can be sent without waiting for the
+// previous answers, as described in RFC 7766 section 6.2.1.1.
+// Responses can arrive out of order and are matched to their query
+// using the DNS message ID. Since different callers may use the same
+// message ID, the ID is rewritten on the wire with an ID unique for
+// the connection, and restored in the response given back.
+type pipeline struct {
+	conn        net.Conn
+	idleTimeout time.Duration
+
+	writeMutex sync.Mutex
+
+	mutex     sync.Mutex
+	pending   map[uint16]chan<- []byte
+	nextID    uint16
+	idleTimer *time.Timer
+	err       error // set once the connection is closed
+	done      chan struct{}
+}
+
+const maxQueriesInFlight = 100
+
+func newPipeline(conn net.Conn, idleTimeout time.Duration) *pipeline {
+	p := &pipeline{
+		conn:        conn,
+		idleTimeout: idleTimeout,
+		pending:     make(map[uint16]chan<- []byte),
+		done:        make(chan struct{}),
+	}
+	p.mutex.Lock()
+	p.idleTimer = time.AfterFunc(idleTimeout, p.closeIfIdle)
+	p.mutex.Unlock()
+	go p.readLoop()
+	return p
+}
+
+// available returns true if the connection is not closed
+// and can accept more queries in flight.
+func (p *pipeline) available() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.err == nil && len(p.pending) < maxQueriesInFlight
+}
+
+func (p *pipeline) closed() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.err != nil
+}
+
+// exchange sends the DNS query wire bytes given and waits for
+// the matching response, until the deadline is reached if it is
+// not zero, or until the context is canceled.
+func (p *pipeline) exchange(ctx context.Context, deadline time.Time,
+	query []byte) (response []byte, err error) {
+	const headerIDLength = 2
+	if len(query) < headerIDLength {
+		return nil, ErrMessageTooShort
+	} else if len(query) > int(^uint16(0)) {
+		return nil, fmt.Errorf("%w: %d bytes", ErrMessageTooLarge, len(query))
+	}
+	originalID := binary.BigEndian.Uint16(query)
+
+	responseCh := make(chan []byte, 1)
+	id, err := p.register(responseCh)
+	if err != nil {
+		return nil, err
+	}
+
+	// Write the length prefixed message with the connection unique ID
+	// in a single write call.
+	buffer := make([]byte, 2+len(query)) //nolint:gomnd
+	binary.BigEndian.PutUint16(buffer, uint16(len(query)))
+	copy(buffer[2:], query)
+	binary.BigEndian.PutUint16(buffer[2:], id)
+
+	p.writeMutex.Lock()
+	_ = p.conn.SetWriteDeadline(deadline)
+	_, err = p.conn.Write(buffer)
+	p.writeMutex.Unlock()
+	if err != nil {
+		p.unregister(id)
+		p.close(err)
+		return nil, err
+	}
+
+	var timer <-chan time.Time
+	if !deadline.IsZero() {
+		t := time.NewTimer(time.Until(deadline))
+		defer t.Stop()
+		timer = t.C
+	}
+
+	select {
+	case response = <-responseCh:
+		binary.BigEndian.PutUint16(response, originalID)
+		return response, nil
+	case <-p.done:
+		p.mutex.Lock()
+		err = p.err
+		p.mutex.Unlock()
+		return nil, err
+	case <-timer:
+		p.unregister(id)
+		return nil, os.ErrDeadlineExceeded
+	case <-ctx.Done():
+		p.unregister(id)
+		return nil, ctx.Err()
+	}
+}
+
+func (p *pipeline) register(responseCh chan<- []byte) (id uint16, err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrPipelineClosed, p.err)
+	} else if len(p.pending) >= maxQueriesInFlight {
+		return 0, ErrPipelineFull
+	}
+
+	for {
+		p.nextID++
+		if _, used := p.pending[p.nextID]; !used {
+			break
+		}
+	}
+	id = p.nextID
+
+	p.pending[id] = responseCh
+	p.idleTimer.Stop()
+	return id, nil
+}
+
+func (p *pipeline) unregister(id uint16) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	delete(p.pending, id)
+	if len(p.pending) == 0 && p.err == nil {
+		p.idleTimer.Reset(p.idleTimeout)
+	}
+}
+
+func (p *pipeline) readLoop() {
+	lengthBytes := make([]byte, 2) //nolint:gomnd
+	for {
+		_, err := io.ReadFull(p.conn, lengthBytes)
+		if err != nil {
+			p.close(err)
+			return
+		}
+
+		length := binary.BigEndian.Uint16(lengthBytes)
+		message := make([]byte, length)
+		_, err = io.ReadFull(p.conn, message)
+		if err != nil {
+			p.close(err)
+			return
+		}
+
+		const headerIDLength = 2
+		if length < headerIDLength {
+			continue
+		}
+		id := binary.BigEndian.Uint16(message)
+
+		p.mutex.Lock()
+		responseCh, ok := p.pending[id]
+		p.mutex.Unlock()
+		if !ok {
+			// the query timed out and was unregistered
+			continue
+		}
+		responseCh <- message // buffered channel of size 1
+		p.unregister(id)
+	}
+}
+
+func (p *pipeline) closeIfIdle() {
+	p.mutex.Lock()
+	idle := len(p.pending) == 0
+	p.mutex.Unlock()
+	if idle {
+		p.close(errIdleTimeout)
+	}
+}
+
+// close closes the underlying connection and makes all
+// the queries waiting for a response fail with the error given.
+// It is safe to call it multiple times.
+func (p *pipeline) close(err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.err != nil {
+		return
+	}
+	if err == nil {
+		err = ErrPipelineClosed
+	}
+	p.err = err
+	p.idleTimer.Stop()
+	close(p.done)
+	_ = p.conn.Close()
+}
@@ -0,0 +1,109 @@
+package dot
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/upstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runReversingServer reads n length prefixed DNS queries from
+// the connection and answers them in the reverse order.
+func runReversingServer(t *testing.T, conn net.Conn, n int) {
+	t.Helper()
+
+	queries := make([][]byte, n)
+	for i := 0; i < n; i++ {
+		lengthBytes := make([]byte, 2)
+		_, err := io.ReadFull(conn, lengthBytes)
+		require.NoError(t, err)
+		queries[i] = make([]byte, binary.BigEndian.Uint16(lengthBytes))
+		_, err = io.ReadFull(conn, queries[i])
+		require.NoError(t, err)
+	}
+
+	for i := n - 1; i >= 0; i-- {
+		query := new(dns.Msg)
+		require.NoError(t, query.Unpack(queries[i]))
+		response := new(dns.Msg).SetReply(query)
+		response.Answer = []dns.RR{&dns.TXT{
+			Hdr: dns.RR_Header{Name: query.Question[0].Name, Rrtype: dns.TypeTXT, Class: dns.ClassINET},
+			Txt: []string{query.Question[0].Name},
+		}}
+		wire, err := response.Pack()
+		require.NoError(t, err)
+		buffer := make([]byte, 2+len(wire))
+		binary.BigEndian.PutUint16(buffer, uint16(len(wire)))
+		copy(buffer[2:], wire)
+		_, err = conn.Write(buffer)
+		require.NoError(t, err)
+	}
+}
+
+func Test_pipeline_outOfOrder(t *testing.T) {
+	t.Parallel()
+
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+
+	pipe := newPipeline(clientConn, time.Minute)
+	defer pipe.close(nil)
+
+	hostnames := []string{"a.com.", "b.com.", "c.com."}
+
+	serverDone := make(chan struct{})
+	go func() {
+		defer close(serverDone)
+		runReversingServer(t, serverConn, len(hostnames))
+	}()
+
+	wg := new(sync.WaitGroup)
+	for _, hostname := range hostnames {
+		wg.Add(1)
+		go func(hostname string) {
+			defer wg.Done()
+
+			request := new(dns.Msg).SetQuestion(hostname, dns.TypeTXT)
+			request.Id = 1 // same ID for all queries
+			conn := &dns.Conn{Conn: newPipelineConn(context.Background(), pipe, upstream.NewPicker(upstream.Random), "", "")}
+
+			client := &dns.Client{}
+			response, _, err := client.ExchangeWithConn(request, conn)
+
+			assert.NoError(t, err)
+			if assert.NotNil(t, response) && assert.Len(t, response.Answer, 1) {
+				assert.Equal(t, uint16(1), response.Id)
+				txt := response.Answer[0].(*dns.TXT)
+				assert.Equal(t, []string{hostname}, txt.Txt)
+			}
+		}(hostname)
+	}
+
+	wg.Wait()
+	<-serverDone
+}
+
+func Test_pipeline_idleTimeout(t *testing.T) {
+	t.Parallel()
+
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+
+	const idleTimeout = time.Millisecond
+	pipe := newPipeline(clientConn, idleTimeout)
+
+	<-pipe.done
+
+	assert.True(t, pipe.closed())
+	assert.False(t, pipe.available())
+	_, err := pipe.exchange(context.Background(), time.Time{}, []byte{0, 1})
+	assert.ErrorIs(t, err, ErrPipelineClosed)
+}
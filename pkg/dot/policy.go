@@ -0,0 +1,161 @@
+package dot
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/qdm12/dns/pkg/blacklist"
+	"github.com/qdm12/dns/pkg/cache"
+	"github.com/qdm12/dns/pkg/upstream"
+	"inet.af/netaddr"
+)
+
+// policy contains the upstream dial function and picker, cache and
+// blacklist used to resolve queries of a group of clients.
+type policy struct {
+	name     string
+	subnets  []netaddr.IPPrefix
+	dial     dialFunc
+	raceDial raceDialFunc
+	upstream upstream.Picker // to list the failing upstream servers
+	race     int             // number of upstream servers to race, 1 to disable
+	timeout  time.Duration   // for an upstream exchange, including retries
+	cache    cache.Cache
+	blist    atomic.Value // blacklistHolder, swapped without blocking queries
+}
+
+// blacklistHolder is used to always store the same concrete
+// type in the policy atomic.Value blacklist field.
+type blacklistHolder struct {
+	blacklist.BlackLister
+}
+
+func newPolicy(name string, subnets []netaddr.IPPrefix,
+	resolverSettings ResolverSettings, cacheSettings cache.Settings,
+	blacklistSettings blacklist.Settings) *policy {
+	dial, raceDial, upstreamPicker := newDoTDial(resolverSettings)
+	p := &policy{
+		name:     name,
+		subnets:  subnets,
+		dial:     dial,
+		raceDial: raceDial,
+		upstream: upstreamPicker,
+		race:     resolverSettings.Race,
+		timeout:  resolverSettings.Timeout,
+		cache:    cache.New(cacheSettings), // defaults to NOOP
+	}
+	p.updateBlacklist(blacklistSettings)
+	return p
+}
+
+func (p *policy) updateBlacklist(settings blacklist.Settings) {
+	p.blist.Store(blacklistHolder{
+		BlackLister: blacklist.NewTree(settings),
+	})
+}
+
+func (p *policy) loadBlacklist() blacklist.BlackLister {
+	return p.blist.Load().(blacklistHolder)
+}
+
+func (p *policy) contains(ip netaddr.IP) bool {
+	for _, subnet := range p.subnets {
+		if subnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// policyFor returns the first client policy with a subnet containing
+// the IP address of the client address given, or the default policy.
+func (h *handler) policyFor(clientAddr net.Addr) *policy {
+	var stdIP net.IP
+	switch addr := clientAddr.(type) {
+	case *net.UDPAddr:
+		stdIP = addr.IP
+	case *net.TCPAddr:
+		stdIP = addr.IP
+	default:
+		return h.defaultPolicy
+	}
+
+	ip, ok := netaddr.FromStdIP(stdIP)
+	if !ok {
+		return h.defaultPolicy
+	}
+
+	for _, p := range h.policies {
+		if p.contains(ip) {
+			return p
+		}
+	}
+	return h.defaultPolicy
+}
+
+// cachedPolicies returns the default policy and the client
+// policies which have a cache.
+func (h *handler) cachedPolicies() (policies []*policy) {
+	policies = make([]*policy, 0, len(h.policies)+1)
+	for _, p := range append([]*policy{h.defaultPolicy}, h.policies...) {
+		if p.cache != nil {
+			policies = append(policies, p)
+		}
+	}
+	return policies
+}
+
+// snapshotPath returns the cache snapshot file path for the policy.
+// The default policy uses the cache snapshot path as is, and client
+// policies use it suffixed with their name.
+func (h *handler) snapshotPath(p *policy) string {
+	if p == h.defaultPolicy {
+		return h.cacheSnapshotPath
+	}
+	return h.cacheSnapshotPath + "." + p.name
+}
+
+// loadCaches loads the cache of each policy from its
+// snapshot file, if cache persistence is enabled.
+func (h *handler) loadCaches() {
+	if h.cacheSnapshotPath == "" {
+		return
+	}
+
+	for _, p := range h.cachedPolicies() {
+		if err := cache.LoadFile(p.cache, h.snapshotPath(p)); err != nil {
+			h.logger.Warn("policy " + p.name + ": " + err.Error())
+		}
+	}
+}
+
+// saveCaches saves the cache of each policy to its
+// snapshot file, if cache persistence is enabled.
+func (h *handler) saveCaches() {
+	if h.cacheSnapshotPath == "" {
+		return
+	}
+
+	for _, p := range h.cachedPolicies() {
+		if err := cache.SaveFile(p.cache, h.snapshotPath(p)); err != nil {
+			h.logger.Warn("policy " + p.name + ": " + err.Error())
+		}
+	}
+}
+
+var ErrPolicyNotFound = errors.New("policy not found")
+
+// UpdatePolicyBlacklist atomically replaces the blacklist
+// of the client policy with the name given.
+func (h *handler) UpdatePolicyBlacklist(name string, settings blacklist.Settings) error {
+	for _, p := range h.policies {
+		if p.name == name {
+			p.updateBlacklist(settings)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrPolicyNotFound, name)
+}
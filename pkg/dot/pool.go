@@ -0,0 +1,95 @@
+package dot
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"sync"
+	"time"
+)
+
+// pool keeps warm pipelined TLS connections to upstream DoT servers,
+// keyed by their TCP address, such that queries do not pay the price
+// of a new TCP and TLS handshake each time.
+type pool struct {
+	dialer      *net.Dialer
+	idleTimeout time.Duration
+
+	mutex     sync.Mutex
+	pipelines map[string][]*pipeline
+}
+
+func newPool(dialer *net.Dialer, idleTimeout time.Duration) *pool {
+	return &pool{
+		dialer:      dialer,
+		idleTimeout: idleTimeout,
+		pipelines:   make(map[string][]*pipeline),
+	}
+}
+
+// get returns a pipelined connection to the address given, re-using
+// an existing one if it is available or dialing a new one otherwise.
+func (p *pool) get(ctx context.Context, address string,
+	tlsConf *tls.Config) (pipe *pipeline, err error) {
+	if pipe = p.getAvailable(address); pipe != nil {
+		return pipe, nil
+	}
+
+	conn, err := p.dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConn := tls.Client(conn, tlsConf)
+	deadline, ok := ctx.Deadline()
+	if !ok && p.dialer.Timeout > 0 {
+		deadline = time.Now().Add(p.dialer.Timeout)
+	}
+	_ = tlsConn.SetDeadline(deadline)
+	if err := tlsConn.Handshake(); err != nil {
+		_ = tlsConn.Close()
+		return nil, err
+	}
+	_ = tlsConn.SetDeadline(time.Time{})
+
+	pipe = newPipeline(tlsConn, p.idleTimeout)
+
+	p.mutex.Lock()
+	p.pipelines[address] = append(p.pipelines[address], pipe)
+	p.mutex.Unlock()
+
+	return pipe, nil
+}
+
+func (p *pool) getAvailable(address string) (pipe *pipeline) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	pipelines := p.pipelines[address]
+
+	// Remove closed connections from the pool
+	i := 0
+	for _, pipe := range pipelines {
+		if !pipe.closed() {
+			pipelines[i] = pipe
+			i++
+		}
+	}
+	for j := i; j < len(pipelines); j++ {
+		pipelines[j] = nil
+	}
+	pipelines = pipelines[:i]
+
+	if len(pipelines) == 0 {
+		delete(p.pipelines, address)
+		return nil
+	}
+	p.pipelines[address] = pipelines
+
+	for _, pipe := range pipelines {
+		if pipe.available() {
+			return pipe
+		}
+	}
+	return nil
+}
@@ -6,10 +6,11 @@ import (
 
 // NewResolver creates a DNS over TLS resolver.
 func NewResolver(settings ResolverSettings) *net.Resolver {
-	settings.setDefaults()
+	settings.SetDefaults()
+	dial, _, _ := newDoTDial(settings)
 	return &net.Resolver{
 		PreferGo:     true,
 		StrictErrors: true,
-		Dial:         newDoTDial(settings),
+		Dial:         dial,
 	}
 }
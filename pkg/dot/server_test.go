@@ -0,0 +1,114 @@
+package dot
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/qdm12/golibs/logging/mock_logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestCertificate(t *testing.T, dir string) (certificatePath, keyPath string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "dns.example.com"},
+		DNSNames:     []string{"dns.example.com"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certificateDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certificatePath = filepath.Join(dir, "cert.pem")
+	certificatePEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificateDER})
+	require.NoError(t, os.WriteFile(certificatePath, certificatePEM, 0600))
+
+	keyPath = filepath.Join(dir, "key.pem")
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	require.NoError(t, os.WriteFile(keyPath, keyPEM, 0600))
+
+	return certificatePath, keyPath
+}
+
+func Test_newTLSConfig(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	certificatePath, keyPath := writeTestCertificate(t, dir)
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+		tlsConfig, err := newTLSConfig(TLSSettings{
+			CertificateFile: certificatePath,
+			KeyFile:         keyPath,
+		})
+		require.NoError(t, err)
+		assert.Len(t, tlsConfig.Certificates, 1)
+		assert.Equal(t, uint16(tls.VersionTLS12), tlsConfig.MinVersion)
+	})
+
+	t.Run("missing key file", func(t *testing.T) {
+		t.Parallel()
+		tlsConfig, err := newTLSConfig(TLSSettings{
+			CertificateFile: certificatePath,
+			KeyFile:         filepath.Join(dir, "missing.pem"),
+		})
+		assert.Error(t, err)
+		assert.Nil(t, tlsConfig)
+	})
+}
+
+func Test_server_Run_listenerFails(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+
+	// Occupy a TCP port so the TCP listener fails to start,
+	// while the UDP listener on the same port starts.
+	tcpListener, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	defer tcpListener.Close()
+	port := uint16(tcpListener.Addr().(*net.TCPAddr).Port)
+
+	logger := mock_logging.NewMockLogger(mockCtrl)
+	logger.EXPECT().Info(gomock.Any()).AnyTimes()
+	settings := ServerSettings{Port: port}
+	settings.SetDefaults()
+	server := NewServer(context.Background(), logger, settings)
+
+	stopped := make(chan error)
+	go server.Run(context.Background(), stopped)
+
+	select {
+	case err = <-stopped:
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "tcp server: ")
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not stop")
+	}
+
+	// The UDP listener is shut down and its port is free again.
+	udpConn, err := net.ListenPacket("udp", tcpListener.Addr().String())
+	require.NoError(t, err)
+	_ = udpConn.Close()
+}
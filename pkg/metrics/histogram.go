@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"strconv"
+	"strings"
+)
+
+// defaultBuckets are the upper bounds in seconds of the histogram
+// buckets, matching the Prometheus client default buckets.
+var defaultBuckets = []float64{ //nolint:gochecknoglobals
+	.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10,
+}
+
+type histogram struct {
+	// counts contains the number of observations for each bucket,
+	// not cumulated. The last element is for the +Inf bucket.
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func newHistogram() *histogram {
+	return &histogram{
+		counts: make([]uint64, len(defaultBuckets)+1),
+	}
+}
+
+func (h *histogram) observe(value float64) {
+	i := 0
+	for ; i < len(defaultBuckets); i++ {
+		if value <= defaultBuckets[i] {
+			break
+		}
+	}
+	h.counts[i]++
+	h.sum += value
+	h.count++
+}
+
+// write writes the histogram samples for the provider given.
+func (h *histogram) write(b *strings.Builder, name, provider string) {
+	var cumulated uint64
+	for i, upperBound := range defaultBuckets {
+		cumulated += h.counts[i]
+		writeSample(b, name+"_bucket",
+			labels("provider", provider, "le", formatFloat(upperBound)),
+			formatUint(cumulated))
+	}
+	cumulated += h.counts[len(defaultBuckets)]
+	writeSample(b, name+"_bucket",
+		labels("provider", provider, "le", "+Inf"), formatUint(cumulated))
+	writeSample(b, name+"_sum", labels("provider", provider), formatFloat(h.sum))
+	writeSample(b, name+"_count", labels("provider", provider), formatUint(h.count))
+}
+
+func formatFloat(f float64) string {
+	const bitSize = 64
+	return strconv.FormatFloat(f, 'g', -1, bitSize)
+}
@@ -0,0 +1,30 @@
+package metrics
+
+import "time"
+
+//go:generate mockgen -destination=mock_$GOPACKAGE/$GOFILE . Interface
+
+// Interface is the interface to record metrics of a DNS server.
+type Interface interface {
+	CacheInterface
+	// Query records a DNS query handled, with its question
+	// type and response code, such as A and NOERROR.
+	Query(qtype, rcode string)
+	// Blocked records a DNS query blocked for the reason given.
+	Blocked(reason string)
+	// UpstreamLatency records the duration of a DNS exchange
+	// with an upstream server of the provider given.
+	UpstreamLatency(provider string, duration time.Duration)
+	// DialError records a failure to dial an upstream server.
+	DialError()
+}
+
+// CacheInterface is the interface to record metrics of a DNS cache.
+type CacheInterface interface {
+	CacheHit()
+	CacheMiss()
+	CacheEviction()
+	// CacheBytes records a change in the total size in bytes
+	// of the cached responses, which can be negative.
+	CacheBytes(delta int)
+}
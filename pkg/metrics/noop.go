@@ -0,0 +1,20 @@
+package metrics
+
+import "time"
+
+type noop struct{}
+
+// NewNoop creates a metrics implementation doing nothing,
+// used when metrics are disabled.
+func NewNoop() Interface {
+	return &noop{}
+}
+
+func (n *noop) CacheHit()                             {}
+func (n *noop) CacheMiss()                            {}
+func (n *noop) CacheEviction()                        {}
+func (n *noop) CacheBytes(int)                        {}
+func (n *noop) Query(string, string)                  {}
+func (n *noop) Blocked(string)                        {}
+func (n *noop) UpstreamLatency(string, time.Duration) {}
+func (n *noop) DialError()                            {}
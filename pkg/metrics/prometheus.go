@@ -0,0 +1,204 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Prometheus records metrics in memory and serves
+// them over HTTP in the Prometheus text format.
+type Prometheus struct {
+	mutex           sync.Mutex
+	queries         map[[2]string]uint64 // qtype, rcode
+	cacheHits       uint64
+	cacheMisses     uint64
+	cacheEvictions  uint64
+	cacheBytes      int64
+	blocked         map[string]uint64     // reason
+	upstreamLatency map[string]*histogram // provider
+	dialErrors      uint64
+}
+
+// NewPrometheus creates a metrics implementation to be served in
+// the Prometheus text format with its ServeHTTP method.
+func NewPrometheus() *Prometheus {
+	return &Prometheus{
+		queries:         make(map[[2]string]uint64),
+		blocked:         make(map[string]uint64),
+		upstreamLatency: make(map[string]*histogram),
+	}
+}
+
+func (p *Prometheus) Query(qtype, rcode string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.queries[[2]string{qtype, rcode}]++
+}
+
+func (p *Prometheus) CacheHit() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.cacheHits++
+}
+
+func (p *Prometheus) CacheMiss() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.cacheMisses++
+}
+
+func (p *Prometheus) CacheEviction() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.cacheEvictions++
+}
+
+func (p *Prometheus) CacheBytes(delta int) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.cacheBytes += int64(delta)
+}
+
+func (p *Prometheus) Blocked(reason string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.blocked[reason]++
+}
+
+func (p *Prometheus) UpstreamLatency(provider string, duration time.Duration) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	h, ok := p.upstreamLatency[provider]
+	if !ok {
+		h = newHistogram()
+		p.upstreamLatency[provider] = h
+	}
+	h.observe(duration.Seconds())
+}
+
+func (p *Prometheus) DialError() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.dialErrors++
+}
+
+func (p *Prometheus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = p.write(w)
+}
+
+// write writes all the metrics in the Prometheus text format.
+func (p *Prometheus) write(w io.Writer) (err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	var b strings.Builder
+
+	writeHeader(&b, "dns_queries_total", "counter",
+		"Number of DNS queries handled by query type and response code.")
+	queryKeys := make([][2]string, 0, len(p.queries))
+	for key := range p.queries {
+		queryKeys = append(queryKeys, key)
+	}
+	sort.Slice(queryKeys, func(i, j int) bool {
+		if queryKeys[i][0] != queryKeys[j][0] {
+			return queryKeys[i][0] < queryKeys[j][0]
+		}
+		return queryKeys[i][1] < queryKeys[j][1]
+	})
+	for _, key := range queryKeys {
+		writeSample(&b, "dns_queries_total",
+			labels("qtype", key[0], "rcode", key[1]), formatUint(p.queries[key]))
+	}
+
+	writeHeader(&b, "dns_cache_hits_total", "counter", "Number of DNS cache hits.")
+	writeSample(&b, "dns_cache_hits_total", "", formatUint(p.cacheHits))
+	writeHeader(&b, "dns_cache_misses_total", "counter", "Number of DNS cache misses.")
+	writeSample(&b, "dns_cache_misses_total", "", formatUint(p.cacheMisses))
+	writeHeader(&b, "dns_cache_evictions_total", "counter",
+		"Number of DNS cache entries evicted to make room for new entries.")
+	writeSample(&b, "dns_cache_evictions_total", "", formatUint(p.cacheEvictions))
+	writeHeader(&b, "dns_cache_bytes", "gauge",
+		"Total size in bytes of the cached DNS responses in wire format.")
+	writeSample(&b, "dns_cache_bytes", "", formatInt(p.cacheBytes))
+
+	writeHeader(&b, "dns_blocked_total", "counter", "Number of DNS queries blocked by reason.")
+	for _, reason := range sortedKeys(p.blocked) {
+		writeSample(&b, "dns_blocked_total", labels("reason", reason), formatUint(p.blocked[reason]))
+	}
+
+	writeHeader(&b, "dns_upstream_latency_seconds", "histogram",
+		"Duration of DNS exchanges with upstream servers by provider.")
+	providers := make([]string, 0, len(p.upstreamLatency))
+	for provider := range p.upstreamLatency {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+	for _, provider := range providers {
+		p.upstreamLatency[provider].write(&b, "dns_upstream_latency_seconds", provider)
+	}
+
+	writeHeader(&b, "dns_dial_errors_total", "counter", "Number of failures to dial upstream servers.")
+	writeSample(&b, "dns_dial_errors_total", "", formatUint(p.dialErrors))
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+func writeHeader(b *strings.Builder, name, metricType, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+func writeSample(b *strings.Builder, name, labels, value string) {
+	b.WriteString(name + labels + " " + value + "\n")
+}
+
+// labels returns the Prometheus labels string for
+// the label name and value pairs given.
+func labels(nameValues ...string) string {
+	pairs := make([]string, 0, len(nameValues))
+	for i := 0; i+1 < len(nameValues); i += 2 {
+		pairs = append(pairs, nameValues[i]+`="`+escapeLabelValue(nameValues[i+1])+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var labelValueReplacer = strings.NewReplacer( //nolint:gochecknoglobals
+	`\`, `\\`,
+	`"`, `\"`,
+	"\n", `\n`,
+)
+
+func escapeLabelValue(s string) string {
+	return labelValueReplacer.Replace(s)
+}
+
+func formatUint(n uint64) string {
+	const base = 10
+	return strconv.FormatUint(n, base)
+}
+
+func formatInt(n int64) string {
+	const base = 10
+	return strconv.FormatInt(n, base)
+}
+
+func sortedKeys(m map[string]uint64) (keys []string) {
+	keys = make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
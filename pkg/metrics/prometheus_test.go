@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Prometheus_ServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	p := NewPrometheus()
+	p.Query("A", "NOERROR")
+	p.Query("A", "NOERROR")
+	p.Query("AAAA", "NXDOMAIN")
+	p.CacheHit()
+	p.CacheMiss()
+	p.CacheMiss()
+	p.CacheBytes(100)
+	p.CacheBytes(-40)
+	p.Blocked("hostname")
+	p.UpstreamLatency(`Cloudflare`, 20*time.Millisecond)
+	p.UpstreamLatency(`Cloudflare`, 2*time.Second)
+	p.DialError()
+
+	request := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	recorder := httptest.NewRecorder()
+	p.ServeHTTP(recorder, request)
+
+	const expectedBody = `# HELP dns_queries_total Number of DNS queries handled by query type and response code.
+# TYPE dns_queries_total counter
+dns_queries_total{qtype="A",rcode="NOERROR"} 2
+dns_queries_total{qtype="AAAA",rcode="NXDOMAIN"} 1
+# HELP dns_cache_hits_total Number of DNS cache hits.
+# TYPE dns_cache_hits_total counter
+dns_cache_hits_total 1
+# HELP dns_cache_misses_total Number of DNS cache misses.
+# TYPE dns_cache_misses_total counter
+dns_cache_misses_total 2
+# HELP dns_cache_evictions_total Number of DNS cache entries evicted to make room for new entries.
+# TYPE dns_cache_evictions_total counter
+dns_cache_evictions_total 0
+# HELP dns_cache_bytes Total size in bytes of the cached DNS responses in wire format.
+# TYPE dns_cache_bytes gauge
+dns_cache_bytes 60
+# HELP dns_blocked_total Number of DNS queries blocked by reason.
+# TYPE dns_blocked_total counter
+dns_blocked_total{reason="hostname"} 1
+# HELP dns_upstream_latency_seconds Duration of DNS exchanges with upstream servers by provider.
+# TYPE dns_upstream_latency_seconds histogram
+dns_upstream_latency_seconds_bucket{provider="Cloudflare",le="0.005"} 0
+dns_upstream_latency_seconds_bucket{provider="Cloudflare",le="0.01"} 0
+dns_upstream_latency_seconds_bucket{provider="Cloudflare",le="0.025"} 1
+dns_upstream_latency_seconds_bucket{provider="Cloudflare",le="0.05"} 1
+dns_upstream_latency_seconds_bucket{provider="Cloudflare",le="0.1"} 1
+dns_upstream_latency_seconds_bucket{provider="Cloudflare",le="0.25"} 1
+dns_upstream_latency_seconds_bucket{provider="Cloudflare",le="0.5"} 1
+dns_upstream_latency_seconds_bucket{provider="Cloudflare",le="1"} 1
+dns_upstream_latency_seconds_bucket{provider="Cloudflare",le="2.5"} 2
+dns_upstream_latency_seconds_bucket{provider="Cloudflare",le="5"} 2
+dns_upstream_latency_seconds_bucket{provider="Cloudflare",le="10"} 2
+dns_upstream_latency_seconds_bucket{provider="Cloudflare",le="+Inf"} 2
+dns_upstream_latency_seconds_sum{provider="Cloudflare"} 2.02
+dns_upstream_latency_seconds_count{provider="Cloudflare"} 2
+# HELP dns_dial_errors_total Number of failures to dial upstream servers.
+# TYPE dns_dial_errors_total counter
+dns_dial_errors_total 1
+`
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, expectedBody, recorder.Body.String())
+}
+
+func Test_escapeLabelValue(t *testing.T) {
+	t.Parallel()
+
+	escaped := escapeLabelValue("a\\b\"c\nd")
+	assert.Equal(t, `a\\b\"c\nd`, escaped)
+}
@@ -0,0 +1,64 @@
+package provider
+
+// The functions below return the built-in providers from the
+// providers catalog, which can be modified with LoadCatalog.
+
+func CiraFamily() Provider {
+	return mustGet("CIRA Family")
+}
+
+func CiraPrivate() Provider {
+	return mustGet("CIRA private")
+}
+
+func CiraProtected() Provider {
+	return mustGet("CIRA Protected")
+}
+
+func CleanBrowsingAdult() Provider {
+	return mustGet("Cleanbrowsing Adult")
+}
+
+func CleanBrowsingFamily() Provider {
+	return mustGet("Cleanbrowsing Family")
+}
+
+func CleanBrowsingSecurity() Provider {
+	return mustGet("Cleanbrowsing Security")
+}
+
+func Cloudflare() Provider {
+	return mustGet("Cloudflare")
+}
+
+func CloudflareFamily() Provider {
+	return mustGet("Cloudflare Family")
+}
+
+func CloudflareSecurity() Provider {
+	return mustGet("Cloudflare Security")
+}
+
+func Google() Provider {
+	return mustGet("Google")
+}
+
+func LibreDNS() Provider {
+	return mustGet("LibreDNS")
+}
+
+func Quad9() Provider {
+	return mustGet("Quad9")
+}
+
+func Quad9Secured() Provider {
+	return mustGet("Quad9 Secured")
+}
+
+func Quad9Unsecured() Provider {
+	return mustGet("Quad9 Unsecured")
+}
+
+func Quadrant() Provider {
+	return mustGet("Quadrant")
+}
@@ -0,0 +1,118 @@
+package provider
+
+import (
+	"bytes"
+	_ "embed" // embed the built-in providers catalog
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// builtInJSON is the built-in providers catalog, using the same
+// JSON schema as custom providers.
+//
+//go:embed providers.json
+var builtInJSON []byte //nolint:gochecknoglobals
+
+var (
+	builtIn = mustParseCatalog(builtInJSON) //nolint:gochecknoglobals
+
+	catalogMutex sync.RWMutex //nolint:gochecknoglobals
+	catalog      = builtIn    //nolint:gochecknoglobals
+)
+
+func mustParseCatalog(b []byte) (providers []Provider) {
+	providers, err := parseCatalog(bytes.NewReader(b))
+	if err != nil {
+		panic(fmt.Sprintf("built-in providers catalog: %s", err))
+	}
+	return providers
+}
+
+var (
+	ErrCatalogDecode    = errors.New("cannot decode providers catalog")
+	ErrCatalogDuplicate = errors.New("provider is defined more than once")
+)
+
+// parseCatalog parses and validates providers from a JSON
+// array of objects with the same schema as custom providers.
+func parseCatalog(r io.Reader) (providers []Provider, err error) {
+	settings, err := decodeSettings(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrCatalogDecode, err)
+	}
+
+	lowercaseNames := make(map[string]struct{}, len(settings))
+	providers = make([]Provider, len(settings))
+	for i, s := range settings {
+		s.SetDefaults()
+		if err := s.validateName(); err != nil {
+			return nil, err
+		} else if err := s.validateServers(); err != nil {
+			return nil, err
+		}
+
+		lowercaseName := strings.ToLower(s.Name)
+		if _, ok := lowercaseNames[lowercaseName]; ok {
+			return nil, fmt.Errorf("%w: %s", ErrCatalogDuplicate, s.Name)
+		}
+		lowercaseNames[lowercaseName] = struct{}{}
+
+		providers[i] = newDataProvider(s)
+	}
+
+	return providers, nil
+}
+
+// LoadCatalog loads providers from a JSON array of objects with the
+// same schema as custom providers. Each provider loaded replaces the
+// built-in provider with the same name, case insensitively, and the
+// other providers loaded are added to the built-in providers.
+// It should be called before any provider is used.
+func LoadCatalog(r io.Reader) (err error) {
+	overrides, err := parseCatalog(r)
+	if err != nil {
+		return err
+	}
+
+	merged := mergeCatalogs(builtIn, overrides)
+
+	catalogMutex.Lock()
+	defer catalogMutex.Unlock()
+	catalog = merged
+	return nil
+}
+
+func mergeCatalogs(base, overrides []Provider) (merged []Provider) {
+	merged = make([]Provider, len(base), len(base)+len(overrides))
+	copy(merged, base)
+	for _, override := range overrides {
+		replaced := false
+		for i := range merged {
+			if strings.EqualFold(merged[i].String(), override.String()) {
+				merged[i] = override
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, override)
+		}
+	}
+	return merged
+}
+
+// mustGet returns the provider with the name given from the catalog,
+// and panics if it is not found.
+func mustGet(name string) Provider {
+	catalogMutex.RLock()
+	defer catalogMutex.RUnlock()
+	for _, provider := range catalog {
+		if strings.EqualFold(name, provider.String()) {
+			return provider
+		}
+	}
+	panic(fmt.Sprintf("provider %q not found in catalog", name))
+}
@@ -0,0 +1,80 @@
+package provider
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseCatalog(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		json  string
+		names []string
+		err   error
+	}{
+		"malformed": {
+			json: `[{"name": "a"`,
+			err:  errors.New("cannot decode providers catalog: unexpected EOF"),
+		},
+		"invalid provider": {
+			json: `[{"name": "acme", "dot_ips": ["1.2.3.4"]}]`,
+			err:  errors.New("custom provider DNS over TLS server name is empty: acme"),
+		},
+		"duplicate name": {
+			json: `[
+				{"name": "Acme", "doh_url": "https://dns.acme.com/dns-query"},
+				{"name": "acme", "doh_url": "https://dns.acme.com/dns-query"}
+			]`,
+			err: errors.New("provider is defined more than once: acme"),
+		},
+		"built-in name": {
+			json:  `[{"name": "cloudflare", "doh_url": "https://1.1.1.1/dns-query"}]`,
+			names: []string{"cloudflare"},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			providers, err := parseCatalog(strings.NewReader(testCase.json))
+
+			if testCase.err != nil {
+				require.Error(t, err)
+				assert.Equal(t, testCase.err.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			var names []string
+			for _, provider := range providers {
+				names = append(names, provider.String())
+			}
+			assert.Equal(t, testCase.names, names)
+		})
+	}
+}
+
+func Test_mergeCatalogs(t *testing.T) {
+	t.Parallel()
+
+	newProvider := func(name, dohURL string) Provider {
+		return newDataProvider(CustomSettings{Name: name, DoHURL: dohURL})
+	}
+	a := newProvider("A", "https://a.com/dns-query")
+	b := newProvider("B", "https://b.com/dns-query")
+	bOverride := newProvider("b", "https://b.org/dns-query")
+	c := newProvider("C", "https://c.com/dns-query")
+
+	base := []Provider{a, b}
+	merged := mergeCatalogs(base, []Provider{c, bOverride})
+
+	assert.Equal(t, []Provider{a, bOverride, c}, merged)
+	assert.Equal(t, []Provider{a, b}, base)
+}
@@ -1,48 +0,0 @@
-package provider
-
-import (
-	"net"
-	"net/url"
-)
-
-type ciraPrivate struct{}
-
-func CiraPrivate() Provider {
-	return &ciraPrivate{}
-}
-
-func (c *ciraPrivate) String() string {
-	return "CIRA private"
-}
-
-func (c *ciraPrivate) DNS() DNSServer {
-	return DNSServer{
-		IPv4: []net.IP{{149, 112, 121, 10}, {149, 112, 122, 10}},
-		IPv6: []net.IP{
-			{0x26, 0x20, 0x1, 0xa, 0x80, 0xbb, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x10},
-			{0x26, 0x20, 0x1, 0xa, 0x80, 0xbc, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x10},
-		},
-	}
-}
-
-func (c *ciraPrivate) DoT() DoTServer {
-	return DoTServer{
-		IPv4: []net.IP{{149, 112, 121, 10}, {149, 112, 122, 10}},
-		IPv6: []net.IP{
-			{0x26, 0x20, 0x1, 0xa, 0x80, 0xbb, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x10},
-			{0x26, 0x20, 0x1, 0xa, 0x80, 0xbc, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x10},
-		},
-		Name: "private.canadianshield.cira.ca",
-		Port: defaultDoTPort,
-	}
-}
-
-func (c *ciraPrivate) DoH() DoHServer {
-	return DoHServer{
-		URL: &url.URL{
-			Scheme: "https",
-			Host:   "private.canadianshield.cira.ca",
-			Path:   "/dns-query",
-		},
-	}
-}
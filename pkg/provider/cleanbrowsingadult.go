@@ -1,49 +0,0 @@
-package provider
-
-import (
-	"net"
-	"net/url"
-)
-
-type cleanBrowsingAdult struct{}
-
-func CleanBrowsingAdult() Provider {
-	return &cleanBrowsingAdult{}
-}
-
-func (c *cleanBrowsingAdult) String() string {
-	return "Cleanbrowsing Adult"
-}
-
-func (c *cleanBrowsingAdult) DNS() DNSServer {
-	return DNSServer{
-		IPv4: []net.IP{{185, 228, 168, 10}, {185, 228, 169, 11}},
-		IPv6: []net.IP{
-			{0x2a, 0xd, 0x2a, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x1},
-			{0x2a, 0xd, 0x2a, 0x0, 0x0, 0x2, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x1},
-		},
-	}
-}
-
-func (c *cleanBrowsingAdult) DoT() DoTServer {
-	return DoTServer{
-		IPv4: []net.IP{{185, 228, 168, 10}, {185, 228, 169, 11}},
-		IPv6: []net.IP{
-			{0x2a, 0xd, 0x2a, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x1},
-			{0x2a, 0xd, 0x2a, 0x0, 0x0, 0x2, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x1},
-		},
-		Name: "adult-filter-dns.cleanbrowsing.org",
-		Port: defaultDoTPort,
-	}
-}
-
-func (c *cleanBrowsingAdult) DoH() DoHServer {
-	// See https://cleanbrowsing.org/guides/dnsoverhttps
-	return DoHServer{
-		URL: &url.URL{
-			Scheme: "https",
-			Host:   "doh.cleanbrowsing.org",
-			Path:   "/doh/adult-filter/",
-		},
-	}
-}
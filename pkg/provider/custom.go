@@ -0,0 +1,120 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+)
+
+// CustomSettings are the settings of a user defined provider.
+type CustomSettings struct {
+	// Name is the name of the provider, used to select it
+	// in the same way as built-in providers.
+	Name string `json:"name"`
+	// DNSIPs are the IP addresses of its plaintext DNS servers.
+	DNSIPs []net.IP `json:"dns_ips"`
+	// DoTIPs are the IP addresses of its DNS over TLS servers.
+	DoTIPs []net.IP `json:"dot_ips"`
+	// DoTName is the TLS server name of its DNS over TLS servers.
+	DoTName string `json:"dot_name"`
+	// DoTPort is the port of its DNS over TLS servers,
+	// and defaults to 853.
+	DoTPort uint16 `json:"dot_port"`
+	// DoHURL is the URL of its DNS over HTTPS server.
+	DoHURL string `json:"doh_url"`
+}
+
+// SetDefaults sets the default DNS over TLS port if it is unset.
+func (s *CustomSettings) SetDefaults() {
+	if s.DoTPort == 0 {
+		s.DoTPort = defaultDoTPort
+	}
+}
+
+var (
+	ErrCustomNameEmpty   = errors.New("custom provider name is empty")
+	ErrCustomNameInvalid = errors.New("custom provider name is invalid")
+	ErrCustomNameBuiltIn = errors.New("custom provider name is the name of a built-in provider")
+	ErrCustomNoServer    = errors.New("custom provider has no DNS over TLS or DNS over HTTPS server")
+	ErrCustomDoTName     = errors.New("custom provider DNS over TLS server name is empty")
+	ErrCustomDoHURL      = errors.New("custom provider DNS over HTTPS URL is invalid")
+)
+
+// Validate returns an error if the settings are invalid.
+func (s *CustomSettings) Validate() (err error) {
+	if err := s.validateName(); err != nil {
+		return err
+	}
+
+	for _, provider := range All() {
+		if strings.EqualFold(s.Name, provider.String()) {
+			return fmt.Errorf("%w: %s", ErrCustomNameBuiltIn, s.Name)
+		}
+	}
+
+	return s.validateServers()
+}
+
+func (s *CustomSettings) validateName() (err error) {
+	switch {
+	case s.Name == "":
+		return ErrCustomNameEmpty
+	case strings.Contains(s.Name, ","):
+		return fmt.Errorf("%w: %q contains a comma", ErrCustomNameInvalid, s.Name)
+	}
+	return nil
+}
+
+func (s *CustomSettings) validateServers() (err error) {
+	if len(s.DoTIPs) == 0 && s.DoHURL == "" {
+		return fmt.Errorf("%w: %s", ErrCustomNoServer, s.Name)
+	}
+
+	if len(s.DoTIPs) > 0 && s.DoTName == "" {
+		return fmt.Errorf("%w: %s", ErrCustomDoTName, s.Name)
+	}
+
+	if s.DoHURL != "" {
+		if _, err := parseDoHURL(s.DoHURL); err != nil {
+			return fmt.Errorf("%w: %s: %s", ErrCustomDoHURL, s.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// Custom returns a provider from the custom settings given,
+// which must be validated with their Validate method first.
+func Custom(settings CustomSettings) Provider {
+	return newDataProvider(settings)
+}
+
+// ParseCustomJSON parses custom providers settings from a JSON
+// array of objects, and validates them.
+func ParseCustomJSON(r io.Reader) (settings []CustomSettings, err error) {
+	settings, err = decodeSettings(r)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode custom providers: %w", err)
+	}
+
+	for i := range settings {
+		settings[i].SetDefaults()
+		if err := settings[i].Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return settings, nil
+}
+
+func decodeSettings(r io.Reader) (settings []CustomSettings, err error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
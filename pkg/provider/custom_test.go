@@ -0,0 +1,206 @@
+package provider
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CustomSettings_Validate(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		settings CustomSettings
+		err      error
+	}{
+		"empty name": {
+			err: ErrCustomNameEmpty,
+		},
+		"name with comma": {
+			settings: CustomSettings{Name: "a,b"},
+			err:      errors.New(`custom provider name is invalid: "a,b" contains a comma`),
+		},
+		"built-in name": {
+			settings: CustomSettings{Name: "Cloudflare"},
+			err:      errors.New("custom provider name is the name of a built-in provider: Cloudflare"),
+		},
+		"no server": {
+			settings: CustomSettings{
+				Name:   "acme",
+				DNSIPs: []net.IP{{1, 2, 3, 4}},
+			},
+			err: errors.New("custom provider has no DNS over TLS or DNS over HTTPS server: acme"),
+		},
+		"DoT without name": {
+			settings: CustomSettings{
+				Name:   "acme",
+				DoTIPs: []net.IP{{1, 2, 3, 4}},
+			},
+			err: errors.New("custom provider DNS over TLS server name is empty: acme"),
+		},
+		"DoH URL not https": {
+			settings: CustomSettings{
+				Name:   "acme",
+				DoHURL: "http://dns.acme.com/dns-query",
+			},
+			err: errors.New("custom provider DNS over HTTPS URL is invalid: " +
+				"acme: scheme is not https or host is empty"),
+		},
+		"valid": {
+			settings: CustomSettings{
+				Name:    "acme",
+				DoTIPs:  []net.IP{{1, 2, 3, 4}},
+				DoTName: "dns.acme.com",
+				DoHURL:  "https://dns.acme.com/dns-query",
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := testCase.settings.Validate()
+
+			if testCase.err != nil {
+				require.Error(t, err)
+				assert.Equal(t, testCase.err.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_Custom(t *testing.T) {
+	t.Parallel()
+
+	provider := Custom(CustomSettings{
+		Name:    "acme",
+		DNSIPs:  []net.IP{net.ParseIP("1.2.3.4"), net.ParseIP("::1")},
+		DoTIPs:  []net.IP{net.ParseIP("5.6.7.8"), net.ParseIP("::2")},
+		DoTName: "dns.acme.com",
+		DoHURL:  "https://dns.acme.com/dns-query",
+	})
+
+	assert.Equal(t, "acme", provider.String())
+	assert.Equal(t, DNSServer{
+		IPv4: []net.IP{{1, 2, 3, 4}},
+		IPv6: []net.IP{net.ParseIP("::1")},
+	}, provider.DNS())
+	assert.Equal(t, DoTServer{
+		IPv4: []net.IP{{5, 6, 7, 8}},
+		IPv6: []net.IP{net.ParseIP("::2")},
+		Name: "dns.acme.com",
+		Port: 853,
+	}, provider.DoT())
+	assert.Equal(t, DoHServer{
+		URL: &url.URL{Scheme: "https", Host: "dns.acme.com", Path: "/dns-query"},
+	}, provider.DoH())
+
+	// modifying the URL returned must not modify the provider
+	provider.DoH().URL.Host = "modified"
+	assert.Equal(t, "dns.acme.com", provider.DoH().URL.Host)
+
+	dohOnly := Custom(CustomSettings{
+		Name:   "acme",
+		DoHURL: "https://dns.acme.com/dns-query",
+	})
+	assert.Empty(t, dohOnly.DoT().IPv4)
+	assert.Empty(t, dohOnly.DoT().IPv6)
+	assert.Nil(t, Custom(CustomSettings{Name: "acme"}).DoH().URL)
+}
+
+func Test_ParseCustomJSON(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		json     string
+		settings []CustomSettings
+		err      error
+	}{
+		"malformed": {
+			json: `{`,
+			err:  errors.New("cannot decode custom providers: unexpected EOF"),
+		},
+		"unknown field": {
+			json: `[{"name": "acme", "unknown": 1}]`,
+			err:  errors.New(`cannot decode custom providers: json: unknown field "unknown"`),
+		},
+		"invalid provider": {
+			json: `[{"name": "acme"}]`,
+			err:  errors.New("custom provider has no DNS over TLS or DNS over HTTPS server: acme"),
+		},
+		"valid": {
+			json: `[
+				{
+					"name": "acme",
+					"dot_ips": ["1.2.3.4"],
+					"dot_name": "dns.acme.com"
+				},
+				{
+					"name": "example",
+					"dot_ips": ["5.6.7.8"],
+					"dot_name": "dns.example.com",
+					"dot_port": 8853,
+					"doh_url": "https://dns.example.com/dns-query"
+				}
+			]`,
+			settings: []CustomSettings{
+				{
+					Name:    "acme",
+					DoTIPs:  []net.IP{net.ParseIP("1.2.3.4")},
+					DoTName: "dns.acme.com",
+					DoTPort: 853,
+				},
+				{
+					Name:    "example",
+					DoTIPs:  []net.IP{net.ParseIP("5.6.7.8")},
+					DoTName: "dns.example.com",
+					DoTPort: 8853,
+					DoHURL:  "https://dns.example.com/dns-query",
+				},
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			settings, err := ParseCustomJSON(strings.NewReader(testCase.json))
+
+			if testCase.err != nil {
+				require.Error(t, err)
+				assert.Equal(t, testCase.err.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, testCase.settings, settings)
+		})
+	}
+}
+
+func Test_Parse_custom(t *testing.T) {
+	t.Parallel()
+
+	custom := Custom(CustomSettings{
+		Name:   "Acme",
+		DoHURL: "https://dns.acme.com/dns-query",
+	})
+
+	provider, err := Parse("acme", custom)
+	require.NoError(t, err)
+	assert.Equal(t, custom, provider)
+
+	provider, err = Parse("cloudflare", custom)
+	require.NoError(t, err)
+	assert.Equal(t, Cloudflare(), provider)
+}
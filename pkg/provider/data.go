@@ -0,0 +1,85 @@
+package provider
+
+import (
+	"errors"
+	"net"
+	"net/url"
+)
+
+// dataProvider is a provider defined by settings, either
+// from the providers catalog or from custom settings.
+type dataProvider struct {
+	settings CustomSettings
+	dohURL   *url.URL // nil if there is no DNS over HTTPS server
+}
+
+// newDataProvider returns a provider from the settings given,
+// which must be validated first.
+func newDataProvider(settings CustomSettings) *dataProvider {
+	settings.SetDefaults()
+	p := &dataProvider{settings: settings}
+	if settings.DoHURL != "" {
+		p.dohURL, _ = parseDoHURL(settings.DoHURL)
+	}
+	return p
+}
+
+func (p *dataProvider) String() string {
+	return p.settings.Name
+}
+
+func (p *dataProvider) DNS() DNSServer {
+	ipv4, ipv6 := splitIPs(p.settings.DNSIPs)
+	return DNSServer{
+		IPv4: ipv4,
+		IPv6: ipv6,
+	}
+}
+
+func (p *dataProvider) DoT() DoTServer {
+	ipv4, ipv6 := splitIPs(p.settings.DoTIPs)
+	return DoTServer{
+		IPv4: ipv4,
+		IPv6: ipv6,
+		Name: p.settings.DoTName,
+		Port: p.settings.DoTPort,
+	}
+}
+
+func (p *dataProvider) DoH() DoHServer {
+	var dohURL *url.URL
+	if p.dohURL != nil {
+		urlCopy := *p.dohURL
+		dohURL = &urlCopy
+	}
+	return DoHServer{
+		URL: dohURL,
+	}
+}
+
+// splitIPs returns copies of the IP addresses given, split
+// by IP family. The slices returned are never nil.
+func splitIPs(ips []net.IP) (ipv4, ipv6 []net.IP) {
+	ipv4 = make([]net.IP, 0, len(ips))
+	ipv6 = make([]net.IP, 0, len(ips))
+	for _, ip := range ips {
+		if ipv4Bytes := ip.To4(); ipv4Bytes != nil {
+			ipv4 = append(ipv4, append(net.IP(nil), ipv4Bytes...))
+		} else {
+			ipv6 = append(ipv6, append(net.IP(nil), ip...))
+		}
+	}
+	return ipv4, ipv6
+}
+
+var errDoHURLNotHTTPS = errors.New("scheme is not https or host is empty")
+
+func parseDoHURL(s string) (dohURL *url.URL, err error) {
+	dohURL, err = url.Parse(s)
+	if err != nil {
+		return nil, err
+	} else if dohURL.Scheme != "https" || dohURL.Host == "" {
+		return nil, errDoHURLNotHTTPS
+	}
+	return dohURL, nil
+}
@@ -1,21 +1,10 @@
 package provider
 
+// All returns all the providers of the providers catalog.
 func All() []Provider {
-	return []Provider{
-		CiraFamily(),
-		CiraPrivate(),
-		CiraProtected(),
-		CleanBrowsingAdult(),
-		CleanBrowsingFamily(),
-		CleanBrowsingSecurity(),
-		Cloudflare(),
-		CloudflareFamily(),
-		CloudflareSecurity(),
-		Google(),
-		LibreDNS(),
-		Quad9(),
-		Quad9Secured(),
-		Quad9Unsecured(),
-		Quadrant(),
-	}
+	catalogMutex.RLock()
+	defer catalogMutex.RUnlock()
+	providers := make([]Provider, len(catalog))
+	copy(providers, catalog)
+	return providers
 }
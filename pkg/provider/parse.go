@@ -8,7 +8,15 @@ import (
 
 var ErrParse = errors.New("cannot parse provider")
 
-func Parse(s string) (provider Provider, err error) {
+// Parse returns the provider with the name given, case insensitively,
+// among the custom providers given and the built-in providers.
+func Parse(s string, customs ...Provider) (provider Provider, err error) {
+	for _, provider := range customs {
+		if strings.EqualFold(s, provider.String()) {
+			return provider, nil
+		}
+	}
+
 	for _, provider := range All() {
 		if strings.EqualFold(s, provider.String()) {
 			return provider, nil
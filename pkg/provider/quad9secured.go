@@ -1,49 +0,0 @@
-package provider
-
-import (
-	"net"
-	"net/url"
-)
-
-type quad9Secured struct{}
-
-func Quad9Secured() Provider {
-	return &quad9Secured{}
-}
-
-func (q *quad9Secured) String() string {
-	return "Quad9 Secured"
-}
-
-func (q *quad9Secured) DNS() DNSServer {
-	return DNSServer{
-		IPv4: []net.IP{{9, 9, 9, 9}, {149, 112, 112, 9}},
-		IPv6: []net.IP{
-			{0x26, 0x20, 0x0, 0xfe, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x9},
-			{0x26, 0x20, 0x0, 0xfe, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0xfe, 0x0, 0x9},
-		},
-	}
-}
-
-func (q *quad9Secured) DoT() DoTServer {
-	return DoTServer{
-		IPv4: []net.IP{{9, 9, 9, 9}, {149, 112, 112, 9}},
-		IPv6: []net.IP{
-			{0x26, 0x20, 0x0, 0xfe, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x9},
-			{0x26, 0x20, 0x0, 0xfe, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0xfe, 0x0, 0x9},
-		},
-		Name: "dns9.quad9.net",
-		Port: defaultDoTPort,
-	}
-}
-
-func (q *quad9Secured) DoH() DoHServer {
-	// See https://developers.quad9.com/speed/public-dns/docs/doh
-	return DoHServer{
-		URL: &url.URL{
-			Scheme: "https",
-			Host:   "dns9.quad9.net",
-			Path:   "/dns-query",
-		},
-	}
-}
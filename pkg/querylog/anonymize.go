@@ -0,0 +1,29 @@
+package querylog
+
+import (
+	"net"
+)
+
+// anonymizeIP anonymizes the IP address string given
+// using the anonymization mode given.
+func anonymizeIP(ip string, mode Anonymization) string {
+	switch mode {
+	case AnonymizeHide:
+		return ""
+	case AnonymizeTruncate:
+		parsed := net.ParseIP(ip)
+		if parsed == nil {
+			return ""
+		}
+		const (
+			ipv4Ones, ipv4Bits = 24, 32
+			ipv6Ones, ipv6Bits = 48, 128
+		)
+		if ipv4 := parsed.To4(); ipv4 != nil {
+			return ipv4.Mask(net.CIDRMask(ipv4Ones, ipv4Bits)).String()
+		}
+		return parsed.Mask(net.CIDRMask(ipv6Ones, ipv6Bits)).String()
+	default:
+		return ip
+	}
+}
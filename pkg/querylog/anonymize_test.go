@@ -0,0 +1,51 @@
+package querylog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_anonymizeIP(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		ip         string
+		mode       Anonymization
+		anonymized string
+	}{
+		"none": {
+			ip:         "192.168.1.53",
+			mode:       AnonymizeNone,
+			anonymized: "192.168.1.53",
+		},
+		"hide": {
+			ip:   "192.168.1.53",
+			mode: AnonymizeHide,
+		},
+		"truncate IPv4": {
+			ip:         "192.168.1.53",
+			mode:       AnonymizeTruncate,
+			anonymized: "192.168.1.0",
+		},
+		"truncate IPv6": {
+			ip:         "2001:db8:abcd:12:1:2:3:4",
+			mode:       AnonymizeTruncate,
+			anonymized: "2001:db8:abcd::",
+		},
+		"truncate invalid": {
+			ip:   "invalid",
+			mode: AnonymizeTruncate,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			anonymized := anonymizeIP(testCase.ip, testCase.mode)
+			assert.Equal(t, testCase.anonymized, anonymized)
+		})
+	}
+}
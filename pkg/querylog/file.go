@@ -0,0 +1,102 @@
+package querylog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+type file struct {
+	settings FileSettings
+	file     *os.File // nil until the first entry is logged
+	size     int64
+	mutex    sync.Mutex
+}
+
+// NewFile creates a logger writing entries as JSON lines to a file,
+// rotating it once it exceeds the maximum size set. The file is only
+// opened when the first entry is logged.
+func NewFile(settings FileSettings) Logger {
+	settings.SetDefaults()
+	return &file{
+		settings: settings,
+	}
+}
+
+func (f *file) Log(entry Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.file != nil && f.size+int64(len(line)) > f.settings.MaxSize {
+		if err := f.rotate(); err != nil {
+			return err
+		}
+	}
+
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return err
+		}
+	}
+
+	n, err := f.file.Write(line)
+	f.size += int64(n)
+	return err
+}
+
+func (f *file) open() (err error) {
+	const perm = 0600
+	f.file, err = os.OpenFile(f.settings.Path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, perm)
+	if err != nil {
+		return fmt.Errorf("cannot open query log file: %w", err)
+	}
+
+	stat, err := f.file.Stat()
+	if err != nil {
+		_ = f.file.Close()
+		f.file = nil
+		return fmt.Errorf("cannot stat query log file: %w", err)
+	}
+	f.size = stat.Size()
+	return nil
+}
+
+// rotate closes the current file and renames it with the suffix .1,
+// shifting existing backups such that at most MaxBackups backups are kept.
+func (f *file) rotate() (err error) {
+	err = f.file.Close()
+	f.file = nil
+	if err != nil {
+		return fmt.Errorf("cannot close query log file: %w", err)
+	}
+
+	backupPath := func(i int) string {
+		return f.settings.Path + "." + strconv.Itoa(i)
+	}
+
+	err = os.Remove(backupPath(f.settings.MaxBackups))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("cannot remove oldest query log file: %w", err)
+	}
+
+	for i := f.settings.MaxBackups - 1; i >= 1; i-- {
+		err = os.Rename(backupPath(i), backupPath(i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot rotate query log file: %w", err)
+		}
+	}
+
+	err = os.Rename(f.settings.Path, backupPath(1))
+	if err != nil {
+		return fmt.Errorf("cannot rotate query log file: %w", err)
+	}
+	return nil
+}
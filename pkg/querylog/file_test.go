@@ -0,0 +1,44 @@
+package querylog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_file_Log(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "queries.log")
+	logger := NewFile(FileSettings{
+		Path:       path,
+		MaxSize:    50,
+		MaxBackups: 2,
+	})
+
+	// Each line is longer than the maximum size, so each
+	// entry logged after the first one triggers a rotation.
+	for _, name := range []string{"a.", "b.", "c.", "d."} {
+		err := logger.Log(Entry{Name: name})
+		require.NoError(t, err)
+	}
+
+	expectedNames := map[string]string{
+		path:        `"name":"d."`,
+		path + ".1": `"name":"c."`,
+		path + ".2": `"name":"b."`,
+	}
+	for path, expectedName := range expectedNames {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, 1, strings.Count(string(data), "\n"))
+		assert.Contains(t, string(data), expectedName)
+	}
+
+	_, err := os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,112 @@
+package querylog
+
+import (
+	"net"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+//go:generate mockgen -destination=mock_$GOPACKAGE/$GOFILE . Logger
+
+// Logger logs DNS queries handled by a DNS server.
+type Logger interface {
+	Log(entry Entry) error
+}
+
+// Entry is a structured record of a DNS query handled.
+type Entry struct {
+	Time     time.Time     `json:"time"`
+	ClientIP string        `json:"client_ip,omitempty"`
+	Name     string        `json:"name"`
+	Type     string        `json:"type"`
+	Rcode    string        `json:"rcode"`
+	Upstream string        `json:"upstream,omitempty"`
+	Latency  time.Duration `json:"latency_ns"`
+	CacheHit bool          `json:"cache_hit"`
+	// Blocked is the reason the query was blocked, and is empty
+	// if the query was not blocked.
+	Blocked string `json:"blocked,omitempty"`
+}
+
+const (
+	// BlockedHostname is the blocked reason for a query
+	// with a blocked hostname.
+	BlockedHostname = "hostname"
+	// BlockedIP is the blocked reason for a query with
+	// a response containing a blocked IP address.
+	BlockedIP = "ip"
+)
+
+// SetQuery sets the entry fields from the client address and request given.
+func (e *Entry) SetQuery(clientAddr net.Addr, request *dns.Msg) {
+	switch addr := clientAddr.(type) {
+	case *net.UDPAddr:
+		e.ClientIP = addr.IP.String()
+	case *net.TCPAddr:
+		e.ClientIP = addr.IP.String()
+	}
+
+	if len(request.Question) > 0 {
+		question := request.Question[0]
+		e.Name = question.Name
+		e.Type = dns.TypeToString[question.Qtype]
+	}
+}
+
+// SetResponse sets the entry fields from the response given,
+// and the latency since the entry time.
+func (e *Entry) SetResponse(response *dns.Msg) {
+	e.Latency = time.Since(e.Time)
+	if response != nil {
+		e.Rcode = dns.RcodeToString[response.Rcode]
+	}
+}
+
+// New creates a query logger logging to all the sinks enabled in
+// the settings, as well as the ring of recent entries kept in memory.
+// The logger is nil if no sink is enabled, and the ring is nil
+// if it is disabled.
+func New(settings Settings) (logger Logger, ring *Ring) {
+	settings.SetDefaults()
+
+	var loggers []Logger
+	if settings.Stdout {
+		loggers = append(loggers, NewWriter(stdout))
+	}
+
+	if settings.File.Path != "" {
+		loggers = append(loggers, NewFile(settings.File))
+	}
+
+	if settings.RingSize > 0 {
+		ring = NewRing(settings.RingSize)
+		loggers = append(loggers, ring)
+	}
+
+	if len(loggers) == 0 {
+		return nil, nil
+	}
+
+	return &multi{
+		loggers:   loggers,
+		anonymize: settings.Anonymize,
+	}, ring
+}
+
+type multi struct {
+	loggers   []Logger
+	anonymize Anonymization
+}
+
+// Log anonymizes the entry and logs it to each of the loggers,
+// returning the first error encountered.
+func (m *multi) Log(entry Entry) (err error) {
+	entry.ClientIP = anonymizeIP(entry.ClientIP, m.anonymize)
+	for _, logger := range m.loggers {
+		if logErr := logger.Log(entry); logErr != nil && err == nil {
+			err = logErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,46 @@
+package querylog
+
+import "sync"
+
+// Ring is a logger keeping the most recent entries in memory.
+type Ring struct {
+	entries []Entry
+	next    int  // index of the next entry to write
+	full    bool // true once all entries are written at least once
+	mutex   sync.RWMutex
+}
+
+// NewRing creates a ring logger keeping the last size entries.
+func NewRing(size int) *Ring {
+	return &Ring{
+		entries: make([]Entry, size),
+	}
+}
+
+func (r *Ring) Log(entry Entry) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.entries[r.next] = entry
+	r.next++
+	if r.next == len(r.entries) {
+		r.next = 0
+		r.full = true
+	}
+	return nil
+}
+
+// Entries returns a copy of the entries kept,
+// from the oldest to the most recent one.
+func (r *Ring) Entries() (entries []Entry) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	if !r.full {
+		entries = make([]Entry, r.next)
+		copy(entries, r.entries[:r.next])
+		return entries
+	}
+	entries = make([]Entry, 0, len(r.entries))
+	entries = append(entries, r.entries[r.next:]...)
+	entries = append(entries, r.entries[:r.next]...)
+	return entries
+}
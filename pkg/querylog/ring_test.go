@@ -0,0 +1,27 @@
+package querylog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Ring(t *testing.T) {
+	t.Parallel()
+
+	ring := NewRing(3)
+	assert.Empty(t, ring.Entries())
+
+	for _, name := range []string{"a.", "b."} {
+		err := ring.Log(Entry{Name: name})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []Entry{{Name: "a."}, {Name: "b."}}, ring.Entries())
+
+	for _, name := range []string{"c.", "d.", "e."} {
+		err := ring.Log(Entry{Name: name})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []Entry{{Name: "c."}, {Name: "d."}, {Name: "e."}}, ring.Entries())
+}
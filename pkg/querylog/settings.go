@@ -0,0 +1,120 @@
+package querylog
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type Settings struct {
+	// Stdout enables logging queries as JSON lines to stdout.
+	Stdout bool
+	// File contains settings to log queries as JSON lines to a file,
+	// and is disabled if its path is empty.
+	File FileSettings
+	// RingSize is the number of most recent queries to keep
+	// in memory, and 0 disables it.
+	RingSize int
+	// Anonymize is the anonymization mode for client IP addresses,
+	// and defaults to AnonymizeNone.
+	Anonymize Anonymization
+}
+
+type FileSettings struct {
+	Path string
+	// MaxSize is the maximum size in bytes of the file before
+	// it gets rotated, and defaults to 10MB.
+	MaxSize int64
+	// MaxBackups is the number of rotated files to keep,
+	// and defaults to 3.
+	MaxBackups int
+}
+
+// Anonymization is the anonymization mode for client IP addresses.
+type Anonymization string
+
+const (
+	// AnonymizeNone logs client IP addresses as they are.
+	AnonymizeNone Anonymization = "none"
+	// AnonymizeTruncate logs client IPv4 addresses with their last
+	// byte zeroed, and client IPv6 addresses with their last 80 bits
+	// zeroed.
+	AnonymizeTruncate Anonymization = "truncate"
+	// AnonymizeHide does not log client IP addresses.
+	AnonymizeHide Anonymization = "hide"
+)
+
+func ListAnonymizations() (modes []Anonymization) {
+	return []Anonymization{
+		AnonymizeNone,
+		AnonymizeTruncate,
+		AnonymizeHide,
+	}
+}
+
+var ErrParseAnonymization = errors.New("cannot parse anonymization mode")
+
+func ParseAnonymization(s string) (mode Anonymization, err error) {
+	for _, M := range ListAnonymizations() {
+		if strings.EqualFold(string(M), s) {
+			return M, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q is unknown", ErrParseAnonymization, s)
+}
+
+func (s *Settings) SetDefaults() {
+	s.File.SetDefaults()
+
+	if string(s.Anonymize) == "" {
+		s.Anonymize = AnonymizeNone
+	}
+}
+
+func (s *FileSettings) SetDefaults() {
+	if s.MaxSize == 0 {
+		const defaultMaxSize = 10 * 1000 * 1000
+		s.MaxSize = defaultMaxSize
+	}
+
+	if s.MaxBackups == 0 {
+		const defaultMaxBackups = 3
+		s.MaxBackups = defaultMaxBackups
+	}
+}
+
+func (s *Settings) String() string {
+	const (
+		subSection = " |--"
+		indent     = "    " // used if lines already contain the subSection
+	)
+	return strings.Join(s.Lines(indent, subSection), "\n")
+}
+
+func (s *Settings) Lines(indent, subSection string) (lines []string) {
+	if !s.Stdout && s.File.Path == "" && s.RingSize == 0 {
+		return []string{subSection + "Query logging is disabled"}
+	}
+
+	if s.Stdout {
+		lines = append(lines, subSection+"Stdout: enabled")
+	}
+
+	if s.File.Path != "" {
+		lines = append(lines, subSection+"File: "+s.File.Path)
+		lines = append(lines, indent+subSection+"Max size: "+
+			strconv.FormatInt(s.File.MaxSize, 10)+" bytes")
+		lines = append(lines, indent+subSection+"Max backups: "+
+			strconv.Itoa(s.File.MaxBackups))
+	}
+
+	if s.RingSize > 0 {
+		lines = append(lines, subSection+"Recent queries kept in memory: "+
+			strconv.Itoa(s.RingSize))
+	}
+
+	lines = append(lines, subSection+"Client IP anonymization: "+string(s.Anonymize))
+
+	return lines
+}
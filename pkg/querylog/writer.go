@@ -0,0 +1,29 @@
+package querylog
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+)
+
+var stdout io.Writer = os.Stdout //nolint:gochecknoglobals
+
+type writer struct {
+	encoder *json.Encoder
+	mutex   sync.Mutex
+}
+
+// NewWriter creates a logger writing entries
+// as JSON lines to the writer given.
+func NewWriter(w io.Writer) Logger {
+	return &writer{
+		encoder: json.NewEncoder(w),
+	}
+}
+
+func (w *writer) Log(entry Entry) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.encoder.Encode(entry)
+}
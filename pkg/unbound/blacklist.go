@@ -1,15 +1,30 @@
 package unbound
 
-import "github.com/qdm12/dns/pkg/blacklist"
+import (
+	"strings"
+
+	"github.com/qdm12/dns/pkg/blacklist"
+)
 
 func convertBlockedToConfigLines(settings blacklist.Settings) (configLines []string) {
-	size := len(settings.FqdnHostnames) + len(settings.IPs) + len(settings.IPPrefixes)
+	size := len(settings.FqdnHostnames) + len(settings.AllowedFqdnHostnames) +
+		len(settings.IPs) + len(settings.IPPrefixes)
 	configLines = make([]string, 0, size)
 
 	for _, blockedHostname := range settings.FqdnHostnames {
+		// Unbound local zones cannot exclude their apex, so a wildcard
+		// hostname blocks its parent domain as well.
+		blockedHostname = strings.TrimPrefix(blockedHostname, "*.")
 		configLines = append(configLines, "  local-zone: \""+blockedHostname+"\" static")
 	}
 
+	// The most specific local zone applies, so allowed hostnames
+	// override blocked parent domains.
+	for _, allowedHostname := range settings.AllowedFqdnHostnames {
+		allowedHostname = strings.TrimPrefix(allowedHostname, "*.")
+		configLines = append(configLines, "  local-zone: \""+allowedHostname+"\" transparent")
+	}
+
 	for _, blockedIP := range settings.IPs {
 		configLines = append(configLines, "  private-address: "+blockedIP.String())
 	}
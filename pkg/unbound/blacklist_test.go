@@ -38,6 +38,17 @@ func Test_convertBlockedToConfigLines(t *testing.T) {
 				"  private-address: 5.5.5.5/16",
 			},
 		},
+		"wildcard and allowed hostnames": {
+			settings: blacklist.Settings{
+				FqdnHostnames:        []string{"*.sitea.", "siteb."},
+				AllowedFqdnHostnames: []string{"ok.siteb."},
+			},
+			configLines: []string{
+				"  local-zone: \"sitea.\" static",
+				"  local-zone: \"siteb.\" static",
+				"  local-zone: \"ok.siteb.\" transparent",
+			},
+		},
 	}
 	for name, tc := range tests {
 		tc := tc
@@ -15,6 +15,7 @@ type Configurator interface {
 	Start(ctx context.Context, verbosityDetailsLevel uint8) (
 		stdoutLines, stderrLines chan string, waitError chan error, err error)
 	Version(ctx context.Context) (version string, err error)
+	RootKeys() (rootKeys []string, err error)
 }
 
 type configurator struct {
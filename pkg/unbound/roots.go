@@ -2,6 +2,7 @@ package unbound
 
 import (
 	"context"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
@@ -62,3 +63,25 @@ func (c *configurator) downloadRootKeys(ctx context.Context) error {
 
 	return file.Close()
 }
+
+// RootKeys returns the root trust anchors DS records written to the
+// root key file by SetupFiles, one per line in presentation format.
+func (c *configurator) RootKeys() (rootKeys []string, err error) {
+	filepath := filepath.Join(c.unboundEtcDir, rootKey)
+	file, err := c.openFile(filepath, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := ioutil.ReadAll(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+
+	return strings.Split(string(b), "\n"), nil
+}
@@ -0,0 +1,111 @@
+package upstream
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// health tracks the health of upstream servers by address, such that
+// servers failing are skipped for a cool-down period doubling with
+// each consecutive failure.
+type health struct {
+	mutex          sync.Mutex
+	addressToState map[string]*upstreamState
+
+	// Mock fields
+	timeNow func() time.Time
+}
+
+type upstreamState struct {
+	failures int // consecutive failures
+	lastErr  error
+	retryAt  time.Time // end of the cool-down period
+}
+
+const (
+	minCooldown = time.Second
+	maxCooldown = 5 * time.Minute
+)
+
+func newHealth() *health {
+	return &health{
+		addressToState: make(map[string]*upstreamState),
+		timeNow:        time.Now,
+	}
+}
+
+// healthy returns true if the upstream server at the address
+// given is not in its cool-down period.
+func (h *health) healthy(address string) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	state, ok := h.addressToState[address]
+	return !ok || !h.timeNow().Before(state.retryAt)
+}
+
+// success resets the health state of the upstream server.
+func (h *health) success(address string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	delete(h.addressToState, address)
+}
+
+// failure records a failure for the upstream server, and puts it
+// in cool-down for a duration starting at one second and doubling
+// with each consecutive failure, up to five minutes.
+func (h *health) failure(address string, err error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	state, ok := h.addressToState[address]
+	if !ok {
+		state = &upstreamState{}
+		h.addressToState[address] = state
+	}
+
+	state.failures++
+	state.lastErr = err
+
+	cooldown := maxCooldown
+	const maxShift = 9 // 2^9 seconds is above the max cool-down
+	if shift := state.failures - 1; shift < maxShift {
+		cooldown = minCooldown << shift
+		if cooldown > maxCooldown {
+			cooldown = maxCooldown
+		}
+	}
+	state.retryAt = h.timeNow().Add(cooldown)
+}
+
+// Health is the health of an upstream server which
+// failed since its last successful exchange.
+type Health struct {
+	Address   string    `json:"address"`
+	Failures  int       `json:"failures"`
+	LastError string    `json:"last_error"`
+	RetryAt   time.Time `json:"retry_at"`
+}
+
+// failing returns the health of the upstream servers which
+// failed since their last success, sorted by address.
+func (h *health) failing() (upstreams []Health) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	upstreams = make([]Health, 0, len(h.addressToState))
+	for address, state := range h.addressToState {
+		upstream := Health{
+			Address:  address,
+			Failures: state.failures,
+			RetryAt:  state.retryAt,
+		}
+		if state.lastErr != nil {
+			upstream.LastError = state.lastErr.Error()
+		}
+		upstreams = append(upstreams, upstream)
+	}
+	sort.Slice(upstreams, func(i, j int) bool {
+		return upstreams[i].Address < upstreams[j].Address
+	})
+	return upstreams
+}
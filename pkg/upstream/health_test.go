@@ -0,0 +1,58 @@
+package upstream
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_health(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(0, 0)
+	h := newHealth()
+	h.timeNow = func() time.Time { return now }
+
+	const address = "1.1.1.1:853"
+	errTest := errors.New("test error")
+
+	assert.True(t, h.healthy(address))
+	assert.Empty(t, h.failing())
+
+	// First failure gives a cool-down of one second
+	h.failure(address, errTest)
+	assert.False(t, h.healthy(address))
+	expectedFailing := []Health{{
+		Address:   address,
+		Failures:  1,
+		LastError: "test error",
+		RetryAt:   now.Add(time.Second),
+	}}
+	assert.Equal(t, expectedFailing, h.failing())
+	now = now.Add(time.Second)
+	assert.True(t, h.healthy(address))
+
+	// Second consecutive failure gives a cool-down of two seconds
+	h.failure(address, errTest)
+	now = now.Add(time.Second)
+	assert.False(t, h.healthy(address))
+	now = now.Add(time.Second)
+	assert.True(t, h.healthy(address))
+
+	// Cool-down is capped
+	for i := 0; i < 100; i++ {
+		h.failure(address, errTest)
+	}
+	now = now.Add(maxCooldown - time.Nanosecond)
+	assert.False(t, h.healthy(address))
+	now = now.Add(time.Nanosecond)
+	assert.True(t, h.healthy(address))
+
+	// Success resets the state
+	h.failure(address, errTest)
+	h.success(address)
+	assert.True(t, h.healthy(address))
+	assert.Empty(t, h.failing())
+}
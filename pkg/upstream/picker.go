@@ -0,0 +1,168 @@
+// Package upstream picks the upstream server to send a query to,
+// according to a selection strategy and the health of the servers.
+package upstream
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/qdm12/golibs/crypto/random/hashmap"
+)
+
+// Picker picks upstream servers identified by their address,
+// skipping servers failing, and is safe for concurrent use.
+type Picker interface {
+	// Pick returns the index of the upstream server to use among
+	// the candidate addresses given, which must not be empty.
+	// Servers in their failure cool-down period are skipped,
+	// unless all of them are.
+	Pick(candidates []string) (index int)
+	// PickN returns the indexes of up to n distinct upstream servers
+	// to use among the candidate addresses given, in order of
+	// preference of the strategy. Servers in their failure cool-down
+	// period are skipped, unless all of them are.
+	PickN(candidates []string, n int) (indexes []int)
+	// Success records a successful exchange with the upstream
+	// server, which took the round trip time given.
+	Success(address string, rtt time.Duration)
+	// Failure records a failed exchange with the upstream server.
+	Failure(address string, err error)
+	// Failing returns the health of the upstream servers which
+	// failed since their last success, sorted by address.
+	Failing() (upstreams []Health)
+}
+
+type picker struct {
+	strategy Strategy
+	health   *health
+	rand     hashmap.Rand
+
+	// Round robin state
+	counter uint32
+
+	// Fastest state
+	rttsMutex sync.RWMutex
+	rtts      map[string]time.Duration // moving average RTT by address
+}
+
+// NewPicker creates a picker using the strategy given,
+// which defaults to Random if empty.
+func NewPicker(strategy Strategy) Picker {
+	switch strategy {
+	case "":
+		strategy = Random
+	case Random, RoundRobin, Fastest, Failover:
+	default: // coding error as an end user should use ParseStrategy
+		panic("unknown upstream strategy: " + strategy)
+	}
+
+	return &picker{
+		strategy: strategy,
+		health:   newHealth(),
+		rand:     hashmap.New(),
+		rtts:     make(map[string]time.Duration),
+	}
+}
+
+func (p *picker) Pick(candidates []string) (index int) {
+	return p.PickN(candidates, 1)[0]
+}
+
+func (p *picker) PickN(candidates []string, n int) (indexes []int) {
+	indexes = make([]int, 0, len(candidates))
+	for i, candidate := range candidates {
+		if p.health.healthy(candidate) {
+			indexes = append(indexes, i)
+		}
+	}
+	if len(indexes) == 0 {
+		for i := range candidates {
+			indexes = append(indexes, i)
+		}
+	}
+
+	if n > len(indexes) {
+		n = len(indexes)
+	}
+
+	if len(indexes) == 1 {
+		return indexes
+	}
+
+	switch p.strategy {
+	case RoundRobin:
+		start := int((atomic.AddUint32(&p.counter, 1) - 1) % uint32(len(indexes)))
+		rotated := make([]int, 0, len(indexes))
+		rotated = append(rotated, indexes[start:]...)
+		indexes = append(rotated, indexes[:start]...)
+	case Fastest:
+		if p.rand.Intn(exploreRatio) == 0 {
+			p.shuffle(indexes, n)
+		} else {
+			p.sortFastest(candidates, indexes)
+		}
+	case Failover:
+	default:
+		p.shuffle(indexes, n)
+	}
+	return indexes[:n]
+}
+
+// exploreRatio is the inverse of the probability of the fastest
+// strategy to pick servers at random, to keep measuring the
+// round trip time of all the servers.
+const exploreRatio = 20
+
+// shuffle randomly shuffles the first n indexes of the slice,
+// picking each of them among the remaining indexes.
+func (p *picker) shuffle(indexes []int, n int) {
+	for i := 0; i < n; i++ {
+		j := i + p.rand.Intn(len(indexes)-i)
+		indexes[i], indexes[j] = indexes[j], indexes[i]
+	}
+}
+
+// sortFastest sorts the indexes by increasing moving average
+// round trip time. Servers not measured yet are sorted first.
+func (p *picker) sortFastest(candidates []string, indexes []int) {
+	p.rttsMutex.RLock()
+	defer p.rttsMutex.RUnlock()
+
+	sort.SliceStable(indexes, func(i, j int) bool {
+		rttI, measuredI := p.rtts[candidates[indexes[i]]]
+		rttJ, measuredJ := p.rtts[candidates[indexes[j]]]
+		if !measuredI || !measuredJ {
+			return !measuredI && measuredJ
+		}
+		return rttI < rttJ
+	})
+}
+
+func (p *picker) Success(address string, rtt time.Duration) {
+	p.health.success(address)
+
+	if p.strategy != Fastest {
+		return
+	}
+
+	p.rttsMutex.Lock()
+	defer p.rttsMutex.Unlock()
+	average, ok := p.rtts[address]
+	if !ok {
+		p.rtts[address] = rtt
+		return
+	}
+	// Exponentially weighted moving average with a weight of 1/4
+	const weightDivisor = 4
+	p.rtts[address] = average + (rtt-average)/weightDivisor
+}
+
+func (p *picker) Failure(address string, err error) {
+	p.health.failure(address, err)
+}
+
+func (p *picker) Failing() (upstreams []Health) {
+	return p.health.failing()
+}
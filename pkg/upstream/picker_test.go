@@ -0,0 +1,93 @@
+package upstream
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_picker_Pick(t *testing.T) {
+	t.Parallel()
+
+	candidates := []string{"a", "b", "c"}
+
+	t.Run("round robin", func(t *testing.T) {
+		t.Parallel()
+		p := NewPicker(RoundRobin)
+		indexes := make([]int, 4)
+		for i := range indexes {
+			indexes[i] = p.Pick(candidates)
+		}
+		assert.Equal(t, []int{0, 1, 2, 0}, indexes)
+	})
+
+	t.Run("ordered failover", func(t *testing.T) {
+		t.Parallel()
+		p := NewPicker(Failover)
+		assert.Equal(t, 0, p.Pick(candidates))
+		p.Failure("a", errors.New("test error"))
+		assert.Equal(t, 1, p.Pick(candidates))
+		p.Failure("b", errors.New("test error"))
+		assert.Equal(t, 2, p.Pick(candidates))
+		p.Failure("c", errors.New("test error"))
+		// all failing so pick among all
+		assert.Equal(t, 0, p.Pick(candidates))
+	})
+
+	t.Run("pick n", func(t *testing.T) {
+		t.Parallel()
+		p := NewPicker(Failover)
+		assert.Equal(t, []int{0, 1}, p.PickN(candidates, 2))
+		p.Failure("a", errors.New("test error"))
+		assert.Equal(t, []int{1, 2}, p.PickN(candidates, 2))
+		assert.Equal(t, []int{1, 2}, p.PickN(candidates, 5))
+
+		random := NewPicker(Random)
+		indexes := random.PickN(candidates, 3)
+		assert.ElementsMatch(t, []int{0, 1, 2}, indexes)
+	})
+
+	t.Run("random skips failing", func(t *testing.T) {
+		t.Parallel()
+		p := NewPicker(Random)
+		p.Failure("a", errors.New("test error"))
+		p.Failure("c", errors.New("test error"))
+		for i := 0; i < 10; i++ {
+			assert.Equal(t, 1, p.Pick(candidates))
+		}
+	})
+
+	t.Run("fastest", func(t *testing.T) {
+		t.Parallel()
+		p := NewPicker(Fastest).(*picker)
+		p.Success("a", 100*time.Millisecond)
+		p.Success("b", 10*time.Millisecond)
+		// c is not measured yet
+		indexes := []int{0, 1, 2}
+		p.sortFastest(candidates, indexes)
+		assert.Equal(t, []int{2, 1, 0}, indexes)
+
+		p.Success("c", 50*time.Millisecond)
+		p.sortFastest(candidates, indexes)
+		assert.Equal(t, []int{1, 2, 0}, indexes)
+
+		// b gets slower, moving average 10 + (290-10)/4 = 80ms
+		p.Success("b", 290*time.Millisecond)
+		assert.Equal(t, 80*time.Millisecond, p.rtts["b"])
+		p.sortFastest(candidates, indexes)
+		assert.Equal(t, []int{2, 1, 0}, indexes)
+	})
+}
+
+func Test_ParseStrategy(t *testing.T) {
+	t.Parallel()
+
+	strategy, err := ParseStrategy("Round-Robin")
+	assert.NoError(t, err)
+	assert.Equal(t, RoundRobin, strategy)
+
+	_, err = ParseStrategy("unknown")
+	assert.ErrorIs(t, err, ErrParseStrategy)
+}
@@ -0,0 +1,43 @@
+package upstream
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Strategy is the strategy used to pick an upstream server.
+type Strategy string
+
+const (
+	// Random picks an upstream server at random.
+	Random Strategy = "random"
+	// RoundRobin picks upstream servers in turn.
+	RoundRobin Strategy = "round-robin"
+	// Fastest picks the upstream server with the lowest
+	// exponentially weighted moving average round trip time.
+	Fastest Strategy = "fastest"
+	// Failover picks the first upstream server in the order
+	// configured, unless it is failing.
+	Failover Strategy = "ordered-failover"
+)
+
+func ListStrategies() (strategies []Strategy) {
+	return []Strategy{
+		Random,
+		RoundRobin,
+		Fastest,
+		Failover,
+	}
+}
+
+var ErrParseStrategy = errors.New("cannot parse upstream strategy")
+
+func ParseStrategy(s string) (strategy Strategy, err error) {
+	for _, S := range ListStrategies() {
+		if strings.EqualFold(string(S), s) {
+			return S, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q is unknown", ErrParseStrategy, s)
+}